@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+)
+
+var ErrConfiguredOwner = errors.New("owners from the configuration cannot be changed")
+
+type Member struct {
+	UserID     int64
+	Role       models.Role
+	Configured bool
+}
+
+// Authorizer resolves the role of a user. Users listed in the configuration are
+// always owners, everybody else gets the role stored in the role store.
+type Authorizer struct {
+	owners []int64
+	store  storage.RoleStore
+}
+
+func NewAuthorizer(ownerIDs []int64, store storage.RoleStore) *Authorizer {
+	authorizer := &Authorizer{store: store}
+	seen := make(map[int64]struct{}, len(ownerIDs))
+	for _, id := range ownerIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		authorizer.owners = append(authorizer.owners, id)
+	}
+	return authorizer
+}
+
+func (a *Authorizer) isConfiguredOwner(userID int64) bool {
+	for _, id := range a.owners {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Authorizer) Role(userID int64) (models.Role, bool) {
+	if a.isConfiguredOwner(userID) {
+		return models.RoleOwner, true
+	}
+	return a.store.Get(userID)
+}
+
+// Allows reports whether the user has at least the required role.
+func (a *Authorizer) Allows(userID int64, required models.Role) bool {
+	role, ok := a.Role(userID)
+	return ok && role.Allows(required)
+}
+
+func (a *Authorizer) SetRole(userID int64, role models.Role) error {
+	if a.isConfiguredOwner(userID) {
+		return ErrConfiguredOwner
+	}
+	return a.store.Set(userID, role)
+}
+
+func (a *Authorizer) RemoveRole(userID int64) error {
+	if a.isConfiguredOwner(userID) {
+		return ErrConfiguredOwner
+	}
+	return a.store.Remove(userID)
+}
+
+// Members returns the team, configured owners first, then stored members by ID.
+func (a *Authorizer) Members() []Member {
+	members := make([]Member, 0, len(a.owners))
+	for _, id := range a.owners {
+		members = append(members, Member{UserID: id, Role: models.RoleOwner, Configured: true})
+	}
+
+	stored := make([]Member, 0)
+	for userID, role := range a.store.List() {
+		if a.isConfiguredOwner(userID) {
+			continue
+		}
+		stored = append(stored, Member{UserID: userID, Role: role})
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].UserID < stored[j].UserID
+	})
+
+	return append(members, stored...)
+}
+
+// MemberIDs returns the IDs of members having at least the required role.
+func (a *Authorizer) MemberIDs(required models.Role) []int64 {
+	ids := make([]int64, 0)
+	for _, member := range a.Members() {
+		if member.Role.Allows(required) {
+			ids = append(ids, member.UserID)
+		}
+	}
+	return ids
+}
@@ -1,15 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/config"
+	"github.com/pureheroky/tg-golang-bot/github"
 	"github.com/pureheroky/tg-golang-bot/handlers"
+	"github.com/pureheroky/tg-golang-bot/httpclient"
+	"github.com/pureheroky/tg-golang-bot/limits"
 	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/scheduler"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
 	"github.com/pureheroky/tg-golang-bot/utils"
 )
 
@@ -19,16 +30,22 @@ func main() {
 		fmt.Println("Error loading .env file:", err)
 	}
 
-	errorLogger, workLogger := utils.SetupLogging()
+	errorLogger, workLogger, auditLogger := utils.SetupLogging()
 	defer func() {
 		if err := recover(); err != nil {
 			errorLogger.Printf("Application panicked: %v", err)
 		}
 	}()
 
-	botToken := os.Getenv("TOKEN")
-	skillsURL := os.Getenv("SKILLS_URL")
-	bot, err := telego.NewBot(botToken, telego.WithDefaultLogger(true, false))
+	cfg, err := config.Load()
+	if err != nil {
+		errorLogger.Fatal("Failed to load config:", err)
+	}
+	if len(cfg.AdminIDs) == 0 {
+		errorLogger.Println("No owners configured, set ADMIN_IDS to receive job requests")
+	}
+
+	bot, err := telego.NewBot(cfg.Token, telego.WithDefaultLogger(true, false))
 	if err != nil {
 		errorLogger.Fatal("Failed to create bot:", err)
 		os.Exit(1)
@@ -39,25 +56,105 @@ func main() {
 	defer bh.Stop()
 	defer bot.StopLongPolling()
 
-	awaitingRequests := &models.AwaitingRequests{
-		M: make(map[int64]bool),
-	}
+	sessions := session.NewManager(15 * time.Minute)
+	sessionsStop := make(chan struct{})
+	defer close(sessionsStop)
+	go sessions.Run(bot, time.Minute, sessionsStop)
 
 	dataStore := &models.DataStore{
 		UserProjectIndex:   make(map[int]int),
 		UserGitCommitIndex: make(map[int]int),
 	}
 
+	tickets, err := storage.NewFileTicketStore(filepath.Join(cfg.DataDir, "tickets.json"))
+	if err != nil {
+		errorLogger.Fatal("Failed to open ticket store:", err)
+	}
+
+	roles, err := storage.NewFileRoleStore(filepath.Join(cfg.DataDir, "roles.json"))
+	if err != nil {
+		errorLogger.Fatal("Failed to open role store:", err)
+	}
+
+	bans, err := storage.NewFileBanStore(filepath.Join(cfg.DataDir, "bans.json"))
+	if err != nil {
+		errorLogger.Fatal("Failed to open ban store:", err)
+	}
+
+	templates, err := storage.NewFileTemplateStore(filepath.Join(cfg.DataDir, "templates.json"))
+	if err != nil {
+		errorLogger.Fatal("Failed to open template store:", err)
+	}
+
+	jobStore, err := storage.NewFileJobStore(filepath.Join(cfg.DataDir, "jobs.json"))
+	if err != nil {
+		errorLogger.Fatal("Failed to open job store:", err)
+	}
+	jobs := scheduler.New(jobStore, errorLogger)
+
+	limiter := limits.NewLimiter(limits.Config{
+		RequestLimit:    cfg.RequestLimit,
+		RequestWindow:   cfg.RequestWindow,
+		DeclineCooldown: cfg.DeclineCooldown,
+		DuplicateWindow: cfg.DuplicateWindow,
+	}, tickets)
+
+	inbox := &models.AdminInbox{
+		M: make(map[int64]*models.InboxFilter),
+	}
+
 	username := "pureheroky"
 	gitApiUrl := "https://api.github.com"
-	gitToken := os.Getenv("GIT_TOKEN")
 
-	if err := utils.LoadData(dataStore, gitApiUrl, username, gitToken, errorLogger); err != nil {
+	httpClient := httpclient.New(cfg.HTTPTimeout, httpclient.RetryPolicy{
+		MaxAttempts: cfg.HTTPRetryAttempts,
+		BaseDelay:   cfg.HTTPRetryDelay,
+		MaxDelay:    cfg.HTTPRetryMaxDelay,
+	})
+
+	gitClient := github.NewClient(gitApiUrl, cfg.GitToken, httpClient, github.Options{
+		PerPage:  cfg.GitPerPage,
+		MaxPages: cfg.GitMaxPages,
+	})
+	refresher := utils.NewDataRefresher(dataStore, gitClient, username, cfg.GitCommitLimit, errorLogger)
+	loadCtx, cancelLoad := context.WithTimeout(context.Background(), 2*time.Minute)
+	err = refresher.Refresh(loadCtx)
+	cancelLoad()
+	if err != nil {
 		errorLogger.Fatal("Failed to load data:", err)
 	}
 
 	workLogger.Println("Bot started successfully.")
 
-	handlers.RegisterHandlers(bh, bot, dataStore, awaitingRequests, skillsURL, errorLogger, workLogger)
+	handlers.RegisterHandlers(bh, bot, &handlers.Deps{
+		DataStore:     dataStore,
+		Refresher:     refresher,
+		Tickets:       tickets,
+		Sessions:      sessions,
+		Inbox:         inbox,
+		Authorizer:    auth.NewAuthorizer(cfg.AdminIDs, roles),
+		Limiter:       limiter,
+		Bans:          bans,
+		Templates:     templates,
+		Scheduler:     jobs,
+		MessageTTL:    cfg.MessageTTL,
+		ReminderAfter: cfg.ReminderAfter,
+		FollowUpAfter: cfg.FollowUpAfter,
+		FeedbackAfter: cfg.FeedbackAfter,
+		Categories:    cfg.Categories,
+		HTTPClient:    httpClient,
+		SkillsURL:     cfg.SkillsURL,
+		ErrorLogger:   errorLogger,
+		WorkLogger:    workLogger,
+		AuditLogger:   auditLogger,
+	})
+
+	jobsStop := make(chan struct{})
+	defer close(jobsStop)
+	go jobs.Run(bot, 10*time.Second, jobsStop)
+	if cfg.GitRefreshInterval > 0 {
+		go refresher.Run(cfg.GitRefreshInterval, jobsStop)
+	}
+
 	bh.Start()
 }
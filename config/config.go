@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+const defaultCategories = "web=Web development,python=Python apps,bots=Bots,consulting=Consulting"
+
+type Config struct {
+	Token     string
+	SkillsURL string
+	GitToken  string
+	DataDir   string
+	AdminIDs  []int64
+
+	// GitRefreshInterval is how often the GitHub projects and commits are fetched again, 0 disables it.
+	GitRefreshInterval time.Duration
+	// GitPerPage and GitMaxPages bound the paginated GitHub listings, GitCommitLimit
+	// is how many of the latest commits are kept per repository.
+	GitPerPage     int
+	GitMaxPages    int
+	GitCommitLimit int
+
+	// HTTPTimeout bounds every attempt of an outbound HTTP call, failed attempts
+	// are retried up to HTTPRetryAttempts times in total, waiting from
+	// HTTPRetryDelay up to HTTPRetryMaxDelay between them.
+	HTTPTimeout       time.Duration
+	HTTPRetryAttempts int
+	HTTPRetryDelay    time.Duration
+	HTTPRetryMaxDelay time.Duration
+
+	RequestLimit    int
+	RequestWindow   time.Duration
+	DeclineCooldown time.Duration
+	DuplicateWindow time.Duration
+
+	// ReminderAfter is how long a ticket may stay new before admins get a
+	// reminder, FollowUpAfter before the requester is told it's still reviewed.
+	ReminderAfter time.Duration
+	FollowUpAfter time.Duration
+	// FeedbackAfter is how long after a decision the requester is asked to rate it, 0 disables the question.
+	FeedbackAfter time.Duration
+
+	Categories []models.Category
+
+	// MessageTTL is how long each kind of message lives before it is deleted, 0 keeps it.
+	MessageTTL map[models.MessageKind]time.Duration
+}
+
+// Load reads the bot configuration from the environment.
+func Load() (*Config, error) {
+	cfg := &Config{
+		Token:     os.Getenv("TOKEN"),
+		SkillsURL: os.Getenv("SKILLS_URL"),
+		GitToken:  os.Getenv("GIT_TOKEN"),
+		DataDir:   getEnv("DATA_DIR", "data"),
+	}
+
+	// ADMIN_IDS takes a comma separated list, USER_ID is kept for older deployments.
+	adminIDs, err := parseIDs(getEnv("ADMIN_IDS", os.Getenv("USER_ID")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ADMIN_IDS: %w", err)
+	}
+	cfg.AdminIDs = adminIDs
+
+	if cfg.GitRefreshInterval, err = time.ParseDuration(getEnv("GIT_REFRESH_INTERVAL", "1h")); err != nil {
+		return nil, fmt.Errorf("invalid GIT_REFRESH_INTERVAL: %w", err)
+	}
+
+	if cfg.GitPerPage, err = strconv.Atoi(getEnv("GIT_PER_PAGE", "100")); err != nil || cfg.GitPerPage < 0 || cfg.GitPerPage > 100 {
+		return nil, fmt.Errorf("invalid GIT_PER_PAGE: must be between 0 and 100")
+	}
+	if cfg.GitMaxPages, err = strconv.Atoi(getEnv("GIT_MAX_PAGES", "10")); err != nil {
+		return nil, fmt.Errorf("invalid GIT_MAX_PAGES: %w", err)
+	}
+	if cfg.GitCommitLimit, err = strconv.Atoi(getEnv("GIT_COMMIT_LIMIT", "5")); err != nil {
+		return nil, fmt.Errorf("invalid GIT_COMMIT_LIMIT: %w", err)
+	}
+
+	if cfg.HTTPTimeout, err = time.ParseDuration(getEnv("HTTP_TIMEOUT", "10s")); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_TIMEOUT: %w", err)
+	}
+	if cfg.HTTPRetryAttempts, err = strconv.Atoi(getEnv("HTTP_RETRY_ATTEMPTS", "3")); err != nil || cfg.HTTPRetryAttempts < 1 {
+		return nil, fmt.Errorf("invalid HTTP_RETRY_ATTEMPTS: must be at least 1")
+	}
+	if cfg.HTTPRetryDelay, err = time.ParseDuration(getEnv("HTTP_RETRY_DELAY", "500ms")); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_RETRY_DELAY: %w", err)
+	}
+	if cfg.HTTPRetryMaxDelay, err = time.ParseDuration(getEnv("HTTP_RETRY_MAX_DELAY", "10s")); err != nil {
+		return nil, fmt.Errorf("invalid HTTP_RETRY_MAX_DELAY: %w", err)
+	}
+
+	if cfg.RequestLimit, err = strconv.Atoi(getEnv("REQUEST_LIMIT", "3")); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_LIMIT: %w", err)
+	}
+	if cfg.RequestWindow, err = time.ParseDuration(getEnv("REQUEST_WINDOW", "24h")); err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_WINDOW: %w", err)
+	}
+	if cfg.DeclineCooldown, err = time.ParseDuration(getEnv("DECLINE_COOLDOWN", "24h")); err != nil {
+		return nil, fmt.Errorf("invalid DECLINE_COOLDOWN: %w", err)
+	}
+	if cfg.DuplicateWindow, err = time.ParseDuration(getEnv("DUPLICATE_WINDOW", "168h")); err != nil {
+		return nil, fmt.Errorf("invalid DUPLICATE_WINDOW: %w", err)
+	}
+
+	if cfg.ReminderAfter, err = time.ParseDuration(getEnv("REMINDER_AFTER", "24h")); err != nil {
+		return nil, fmt.Errorf("invalid REMINDER_AFTER: %w", err)
+	}
+	if cfg.FollowUpAfter, err = time.ParseDuration(getEnv("FOLLOWUP_AFTER", "72h")); err != nil {
+		return nil, fmt.Errorf("invalid FOLLOWUP_AFTER: %w", err)
+	}
+	if cfg.FeedbackAfter, err = time.ParseDuration(getEnv("FEEDBACK_AFTER", "1h")); err != nil {
+		return nil, fmt.Errorf("invalid FEEDBACK_AFTER: %w", err)
+	}
+
+	if cfg.Categories, err = parseCategories(getEnv("CATEGORIES", defaultCategories), os.Getenv("CATEGORY_CHATS")); err != nil {
+		return nil, err
+	}
+
+	// MESSAGE_TTL_ACCEPTED=5m sets the TTL of acceptance messages and so on.
+	cfg.MessageTTL = make(map[models.MessageKind]time.Duration, len(models.MessageKinds))
+	for _, kind := range models.MessageKinds {
+		key := "MESSAGE_TTL_" + strings.ToUpper(string(kind))
+		ttl, err := time.ParseDuration(getEnv(key, "2m"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", key, err)
+		}
+		cfg.MessageTTL[kind] = ttl
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func parseIDs(value string) ([]int64, error) {
+	ids := make([]int64, 0)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseCategories reads CATEGORIES ("web=Web development,bots=Bots") and
+// CATEGORY_CHATS ("bots=-1001234567890"), which routes a category to a chat.
+func parseCategories(value, chats string) ([]models.Category, error) {
+	categories := make([]models.Category, 0)
+	index := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		key, title, _ := strings.Cut(strings.TrimSpace(part), "=")
+		key, title = strings.TrimSpace(key), strings.TrimSpace(title)
+		if key == "" {
+			continue
+		}
+		if title == "" {
+			title = key
+		}
+		index[key] = len(categories)
+		categories = append(categories, models.Category{Key: key, Title: title})
+	}
+
+	for _, part := range strings.Split(chats, ",") {
+		key, rawID, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		i, ok := index[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("invalid CATEGORY_CHATS: unknown category %q", key)
+		}
+		chatID, err := strconv.ParseInt(strings.TrimSpace(rawID), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CATEGORY_CHATS: %w", err)
+		}
+		categories[i].ChatID = chatID
+	}
+
+	return categories, nil
+}
@@ -0,0 +1,237 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/httpclient"
+)
+
+// StatusError is returned for responses with a status code other than 2xx.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// Message is the "message" field GitHub puts in error bodies, if any.
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %d %s", e.URL, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// RateLimitError is returned when GitHub refused the request because of its
+// rate limit, or would refuse it, until Reset.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return "rate limit exceeded until " + e.Reset.Format(time.TimeOnly)
+}
+
+// Options limits how much of the listings is fetched. Zero PerPage keeps
+// GitHub's default page size, zero MaxPages means no limit.
+type Options struct {
+	PerPage  int
+	MaxPages int
+}
+
+type cachedResponse struct {
+	etag   string
+	body   []byte
+	header http.Header
+}
+
+// Client talks to the GitHub REST API. It keeps the last response with an ETag
+// of every GET request, a 304 Not Modified answer to If-None-Match doesn't
+// count against the rate limit, and stops sending requests while the rate
+// limit is exhausted.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *httpclient.Client
+	options    Options
+
+	mu             sync.Mutex
+	cache          map[string]cachedResponse
+	rateLimitReset time.Time
+}
+
+func NewClient(baseURL, token string, httpClient *httpclient.Client, options Options) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: httpClient,
+		options:    options,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+func (c *Client) get(ctx context.Context, requestURL string) ([]byte, http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "token "+c.token)
+	}
+
+	c.mu.Lock()
+	reset := c.rateLimitReset
+	cached, hasCached := c.cache[requestURL]
+	c.mu.Unlock()
+
+	if time.Now().Before(reset) {
+		return nil, nil, &RateLimitError{Reset: reset}
+	}
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	c.updateRateLimit(resp.Header)
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.body, cached.header, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, c.responseError(requestURL, resp, body)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		c.mu.Lock()
+		c.cache[requestURL] = cachedResponse{etag: etag, body: body, header: resp.Header}
+		c.mu.Unlock()
+	}
+
+	return body, resp.Header, nil
+}
+
+// updateRateLimit remembers when an exhausted rate limit resets, from the
+// X-RateLimit-* headers GitHub sends with every response.
+func (c *Client) updateRateLimit(header http.Header) {
+	remaining := header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		return
+	}
+
+	var reset time.Time
+	if remaining == "0" {
+		if seconds, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			reset = time.Unix(seconds, 0)
+		}
+	}
+
+	c.mu.Lock()
+	c.rateLimitReset = reset
+	c.mu.Unlock()
+}
+
+func (c *Client) responseError(requestURL string, resp *http.Response, body []byte) error {
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		// Secondary rate limits come with Retry-After instead of an exhausted limit.
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			reset := time.Now().Add(time.Duration(seconds) * time.Second)
+			c.mu.Lock()
+			c.rateLimitReset = reset
+			c.mu.Unlock()
+			return &RateLimitError{Reset: reset}
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			seconds, _ := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+			return &RateLimitError{Reset: time.Unix(seconds, 0)}
+		}
+	}
+
+	statusErr := &StatusError{URL: requestURL, StatusCode: resp.StatusCode}
+	var payload struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &payload) == nil {
+		statusErr.Message = payload.Message
+	}
+	return statusErr
+}
+
+// list reads a listing page by page, following the rel="next" links, until the
+// last page, MaxPages pages or limit items (0 for no limit).
+func list[T any](ctx context.Context, c *Client, path string, limit int) ([]T, error) {
+	perPage := c.options.PerPage
+	if limit > 0 && (perPage <= 0 || limit < perPage) {
+		perPage = limit
+	}
+
+	listURL := c.baseURL + path
+	if perPage > 0 {
+		listURL += "?per_page=" + strconv.Itoa(perPage)
+	}
+
+	items := make([]T, 0)
+	for page := 0; listURL != "" && (c.options.MaxPages <= 0 || page < c.options.MaxPages); page++ {
+		body, header, err := c.get(ctx, listURL)
+		if err != nil {
+			return nil, err
+		}
+
+		var data []T
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+		}
+		items = append(items, data...)
+
+		if limit > 0 && len(items) >= limit {
+			return items[:limit], nil
+		}
+		listURL = nextPageURL(header.Get("Link"))
+	}
+
+	return items, nil
+}
+
+// nextPageURL extracts the rel="next" target of a Link header such as
+// <https://api.github.com/user/repos?page=2>; rel="next", <...>; rel="last".
+func nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		params := strings.Split(part, ";")
+		target := strings.TrimSpace(params[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range params[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(target, "<>")
+			}
+		}
+	}
+	return ""
+}
+
+func pathEscape(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
@@ -0,0 +1,304 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/httpclient"
+)
+
+// newTestClient points a client at a local stand-in for the GitHub API.
+func newTestClient(t *testing.T, handler http.HandlerFunc, options Options) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	httpClient := httpclient.New(5*time.Second, httpclient.RetryPolicy{MaxAttempts: 1})
+	return NewClient(server.URL, "", httpClient, options)
+}
+
+// pagedRepos serves total repositories in pages of per_page items, linking
+// each page to the next one like GitHub does.
+func pagedRepos(total int, requests *atomic.Int32, perPages chan<- string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if perPages != nil {
+			perPages <- r.URL.Query().Get("per_page")
+		}
+
+		perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if err != nil || perPage <= 0 {
+			perPage = 30
+		}
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		if err != nil || page <= 0 {
+			page = 1
+		}
+
+		start := (page - 1) * perPage
+		end := min(start+perPage, total)
+		if end < total {
+			next := fmt.Sprintf("http://%s%s?per_page=%d&page=%d", r.Host, r.URL.Path, perPage, page+1)
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next", <http://%s%s?page=99>; rel="last"`, next, r.Host, r.URL.Path))
+		}
+
+		fmt.Fprint(w, "[")
+		for i := start; i < end; i++ {
+			if i > start {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprintf(w, `{"name":"repo-%d"}`, i)
+		}
+		fmt.Fprint(w, "]")
+	}
+}
+
+func TestListFollowsNextLinks(t *testing.T) {
+	var requests atomic.Int32
+	perPages := make(chan string, 10)
+	client := newTestClient(t, pagedRepos(7, &requests, perPages), Options{PerPage: 3})
+
+	repos, err := client.Repositories(context.Background(), "someone")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 7 {
+		t.Fatalf("got %d repositories, want 7", len(repos))
+	}
+	for i, repo := range repos {
+		if want := fmt.Sprintf("repo-%d", i); repo.Name != want {
+			t.Errorf("repository %d is %q, want %q", i, repo.Name, want)
+		}
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("made %d requests, want 3", got)
+	}
+	close(perPages)
+	for perPage := range perPages {
+		if perPage != "3" {
+			t.Errorf("requested per_page=%q, want 3", perPage)
+		}
+	}
+}
+
+func TestListStopsAtMaxPages(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, pagedRepos(10, &requests, nil), Options{PerPage: 2, MaxPages: 2})
+
+	repos, err := client.Repositories(context.Background(), "someone")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 4 {
+		t.Errorf("got %d repositories, want 4", len(repos))
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+}
+
+func TestListStopsAtLimit(t *testing.T) {
+	var requests atomic.Int32
+	client := newTestClient(t, pagedRepos(10, &requests, nil), Options{PerPage: 4})
+
+	repos, err := list[Repository](context.Background(), client, "/users/someone/repos", 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 6 {
+		t.Errorf("got %d repositories, want 6", len(repos))
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+}
+
+func TestListShrinksPageToLimit(t *testing.T) {
+	var requests atomic.Int32
+	perPages := make(chan string, 10)
+	client := newTestClient(t, pagedRepos(10, &requests, perPages), Options{PerPage: 100})
+
+	repos, err := list[Repository](context.Background(), client, "/users/someone/repos", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repos) != 5 {
+		t.Errorf("got %d repositories, want 5", len(repos))
+	}
+	if perPage := <-perPages; perPage != "5" {
+		t.Errorf("requested per_page=%q, want 5", perPage)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	tests := []struct {
+		link string
+		want string
+	}{
+		{"", ""},
+		{`<https://api.github.com/user/repos?page=2>; rel="next", <https://api.github.com/user/repos?page=5>; rel="last"`, "https://api.github.com/user/repos?page=2"},
+		{`<https://api.github.com/user/repos?page=1>; rel="first", <https://api.github.com/user/repos?page=4>; rel="prev"`, ""},
+		{`<https://api.github.com/user/repos?page=1>; rel="prev",<https://api.github.com/user/repos?page=3>; rel="next"`, "https://api.github.com/user/repos?page=3"},
+		{`https://api.github.com/user/repos?page=2; rel="next"`, ""},
+	}
+
+	for _, test := range tests {
+		if got := nextPageURL(test.link); got != test.want {
+			t.Errorf("nextPageURL(%q) = %q, want %q", test.link, got, test.want)
+		}
+	}
+}
+
+func TestGetRevalidatesWithETag(t *testing.T) {
+	var requests, notModified atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[{"name":"cached"}]`)
+	}, Options{})
+
+	for i := 0; i < 2; i++ {
+		repos, err := client.Repositories(context.Background(), "someone")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(repos) != 1 || repos[0].Name != "cached" {
+			t.Fatalf("request %d returned %+v, want the cached repository", i+1, repos)
+		}
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d requests, want 2", got)
+	}
+	if got := notModified.Load(); got != 1 {
+		t.Errorf("got %d revalidated requests, want 1", got)
+	}
+}
+
+func TestRateLimitStopsRequests(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	var requests atomic.Int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"API rate limit exceeded"}`)
+	}, Options{})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Repositories(context.Background(), "someone")
+		var limited *RateLimitError
+		if !errors.As(err, &limited) {
+			t.Fatalf("request %d returned %v, want a RateLimitError", i+1, err)
+		}
+		if !limited.Reset.Equal(reset) {
+			t.Errorf("rate limit resets at %v, want %v", limited.Reset, reset)
+		}
+	}
+
+	// The second call is refused without asking GitHub.
+	if got := requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestRetryAfterIsRateLimit(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit."}`)
+	}, Options{})
+
+	_, err := client.Repositories(context.Background(), "someone")
+	var limited *RateLimitError
+	if !errors.As(err, &limited) {
+		t.Fatalf("got %v, want a RateLimitError", err)
+	}
+	if wait := time.Until(limited.Reset); wait < 50*time.Second || wait > 60*time.Second {
+		t.Errorf("rate limit resets in %v, want about a minute", wait)
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+	}, Options{})
+
+	_, err := client.Commits(context.Background(), "someone", "missing", 5)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("got %v, want a StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusNotFound || statusErr.Message != "Not Found" {
+		t.Errorf("got status %d with message %q, want 404 Not Found", statusErr.StatusCode, statusErr.Message)
+	}
+	if statusErr.URL == "" {
+		t.Error("StatusError has no URL")
+	}
+}
+
+func TestLatestCommitsSkipsEmptyRepositories(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/someone/empty/commits":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"message":"Git Repository is empty."}`)
+		case "/repos/someone/broken/commits":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			fmt.Fprint(w, `[{"sha":"abc","commit":{"message":"first"}}]`)
+		}
+	}, Options{})
+	errorLogger := log.New(io.Discard, "", 0)
+
+	commits, err := client.LatestCommits(context.Background(), "someone", []Repository{{Name: "full"}, {Name: "empty"}}, 5, errorLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || len(commits["full"]) != 1 {
+		t.Errorf("got %+v, want the commit of the full repository only", commits)
+	}
+
+	_, err = client.LatestCommits(context.Background(), "someone", []Repository{{Name: "full"}, {Name: "broken"}}, 5, errorLogger)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("got %v, want the 500 of the broken repository", err)
+	}
+}
+
+func TestLatestCommitsReportsCancellation(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	}, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.LatestCommits(ctx, "someone", []Repository{{Name: "full"}}, 5, log.New(io.Discard, "", 0))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
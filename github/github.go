@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Repository struct {
+	NodeID        string    `json:"node_id"`
+	Name          string    `json:"name"`
+	FullName      string    `json:"full_name"`
+	Description   string    `json:"description"`
+	URL           string    `json:"url"`
+	HTMLURL       string    `json:"html_url"`
+	Language      string    `json:"language"`
+	DefaultBranch string    `json:"default_branch"`
+	Fork          bool      `json:"fork"`
+	Stars         int       `json:"stargazers_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	PushedAt      time.Time `json:"pushed_at"`
+}
+
+type Author struct {
+	Name  string    `json:"name"`
+	Email string    `json:"email"`
+	Date  time.Time `json:"date"`
+}
+
+type Commit struct {
+	SHA       string
+	HTMLURL   string
+	Message   string
+	Author    Author
+	Committer Author
+}
+
+// apiCommit is a commit as listed by the API, the git data is nested in it.
+type apiCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message   string `json:"message"`
+		Author    Author `json:"author"`
+		Committer Author `json:"committer"`
+	} `json:"commit"`
+}
+
+// Repositories lists the public repositories of the user.
+func (c *Client) Repositories(ctx context.Context, username string) ([]Repository, error) {
+	return list[Repository](ctx, c, pathEscape("users", username, "repos"), 0)
+}
+
+// Commits lists up to limit latest commits of the repository, 0 for all of them.
+func (c *Client) Commits(ctx context.Context, owner, repo string, limit int) ([]Commit, error) {
+	listed, err := list[apiCommit](ctx, c, pathEscape("repos", owner, repo, "commits"), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]Commit, 0, len(listed))
+	for _, commit := range listed {
+		commits = append(commits, Commit{
+			SHA:       commit.SHA,
+			HTMLURL:   commit.HTMLURL,
+			Message:   commit.Commit.Message,
+			Author:    commit.Commit.Author,
+			Committer: commit.Commit.Committer,
+		})
+	}
+	return commits, nil
+}
+
+// commitWorkers is how many repositories LatestCommits fetches at once, GitHub
+// answers bursts of parallel requests with its secondary rate limit.
+const commitWorkers = 4
+
+// LatestCommits fetches the latest commits of every repository a few at a
+// time, keyed by repository name. Empty repositories, which GitHub answers
+// with 409 Conflict, and ones gone since they were listed are left out. Any
+// other failure, a cancelled ctx included, would leave the result incomplete
+// and is returned instead.
+func (c *Client) LatestCommits(ctx context.Context, owner string, repositories []Repository, limit int, errorLogger *log.Logger) (map[string][]Commit, error) {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	output := make(map[string][]Commit, len(repositories))
+	names := make(chan string)
+	for i := 0; i < min(commitWorkers, len(repositories)); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for name := range names {
+				mu.Lock()
+				failed := firstErr != nil
+				mu.Unlock()
+				if failed {
+					continue
+				}
+
+				commits, err := c.Commits(ctx, owner, name, limit)
+				mu.Lock()
+				switch {
+				case err == nil:
+					if len(commits) > 0 {
+						output[name] = commits
+					}
+				case missingCommits(err):
+					errorLogger.Printf("Skipping commits of %s: %v", name, err)
+				case firstErr == nil:
+					firstErr = fmt.Errorf("fetch commits of %s: %w", name, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, repository := range repositories {
+		names <- repository.Name
+	}
+	close(names)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return output, nil
+}
+
+// missingCommits reports whether the repository has no commits to list.
+func missingCommits(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) &&
+		(statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusConflict)
+}
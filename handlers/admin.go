@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const inboxPageSize = 5
+
+func adminCallbackPredicate() th.Predicate {
+	return th.Or(
+		th.CallbackDataPrefix("ticket_"),
+		th.CallbackDataEqual("next_requests"),
+		th.CallbackDataEqual("previous_requests"),
+		th.CallbackDataEqual("requests_back"),
+		th.CallbackDataEqual("prompt_cancel"),
+	)
+}
+
+func adminCallbackHandler(_ *telego.Bot, tickets storage.TicketStore, templates storage.TemplateStore, cleaner *messageCleaner, feedback *ticketFeedback, sessions *session.Manager, inbox *models.AdminInbox, authorizer *auth.Authorizer, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+	return func(bot *telego.Bot, query telego.CallbackQuery) {
+		workLogger.Printf("Received admin callback query from user %d: %s", query.From.ID, query.Data)
+
+		editedMessage := telego.EditMessageTextParams{
+			ChatID:    tu.ID(query.Message.GetChat().ID),
+			MessageID: query.Message.GetMessageID(),
+			ParseMode: telego.ModeHTML,
+		}
+
+		switch query.Data {
+		case "next_requests", "previous_requests":
+			handleInboxPagination(bot, query, tickets, inbox, editedMessage, errorLogger)
+			return
+		case "requests_back":
+			handleInboxBackCallback(bot, query, tickets, inbox, editedMessage, errorLogger)
+			return
+		case "prompt_cancel":
+			handlePromptCancelCallback(bot, query, sessions)
+			return
+		}
+
+		action, id, ok := parseTicketCallback(query.Data)
+		if !ok {
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+			return
+		}
+
+		ticket, err := tickets.Get(id)
+		if err != nil {
+			errorLogger.Printf("Failed to load ticket %d: %v", id, err)
+			answerCallback(bot, query, fmt.Sprintf("Request #%d not found.", id))
+			return
+		}
+
+		if action != "view" && action != "claim" && !canHandleTicket(authorizer, query.From.ID, ticket) {
+			answerCallback(bot, query, fmt.Sprintf("Request #%d is claimed by %s.", ticket.ID, ticket.AssigneeName))
+			return
+		}
+
+		switch action {
+		case "view":
+			editedMessage.Text = utils.FormatTicketDetails(ticket)
+			editedMessage.ReplyMarkup = markup.GetTicketMarkup(ticket)
+			bot.EditMessageText(&editedMessage)
+		case "accept":
+			if !ticket.CanTransition(models.TicketAccepted) {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+				return
+			}
+			if acceptTemplates := templates.List(models.TemplateAccept); len(acceptTemplates) > 0 {
+				handleTicketAcceptCallback(bot, query, ticket, sessions, acceptTemplates, errorLogger)
+				return
+			}
+			accepted, err := acceptTicket(bot, tickets, cleaner, ticket.ID, query.From, "", errorLogger)
+			if err != nil {
+				answerCallback(bot, query, ticketDecisionFailed(err, errorLogger))
+				return
+			}
+			renderTicketView(bot, accepted, query.Message.GetChat().ID, query.Message.GetMessageID())
+		case "accept_plain":
+			if !ticket.CanTransition(models.TicketAccepted) {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+				return
+			}
+			messageID := finishTicketPrompt(bot, query, sessions, ticket.ID)
+			accepted, err := acceptTicket(bot, tickets, cleaner, ticket.ID, query.From, "", errorLogger)
+			if err != nil {
+				answerCallback(bot, query, ticketDecisionFailed(err, errorLogger))
+				return
+			}
+			renderTicketView(bot, accepted, query.Message.GetChat().ID, messageID)
+		case "decline":
+			if !ticket.CanTransition(models.TicketDeclined) {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+				return
+			}
+			handleTicketDeclineCallback(bot, query, ticket, sessions, templates.List(models.TemplateDecline), errorLogger)
+		case "template":
+			handleTicketTemplateCallback(bot, query, tickets, cleaner, feedback, sessions, templates, ticket, errorLogger)
+		case "claim":
+			handleTicketClaimCallback(bot, query, tickets, authorizer, ticket.ID, errorLogger)
+		case "ask":
+			if ticket.Status != models.TicketNew && ticket.Status != models.TicketAccepted {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+				return
+			}
+			handleTicketAskCallback(bot, query, ticket, sessions, errorLogger)
+		case "close":
+			if !ticket.CanTransition(models.TicketClosed) {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+				return
+			}
+			closed, err := closeTicket(bot, tickets, feedback, ticket.ID, errorLogger)
+			if err != nil {
+				answerCallback(bot, query, ticketDecisionFailed(err, errorLogger))
+				return
+			}
+			renderTicketView(bot, closed, query.Message.GetChat().ID, query.Message.GetMessageID())
+		default:
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+		}
+	}
+}
+
+func requestsCommandHandler(_ *telego.Bot, tickets storage.TicketStore, inbox *models.AdminInbox, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+
+		filter := &models.InboxFilter{}
+		if len(parts) > 1 && parts[1] != "all" {
+			status, ok := models.ParseTicketStatus(parts[1])
+			if !ok {
+				sendText(bot, chatID, "Usage: /requests [all|new|accepted|declined|closed|withdrawn]", errorLogger)
+				return
+			}
+			filter.Status = status
+		}
+
+		sendInboxPage(bot, chatID, tickets, inbox, filter, errorLogger)
+	}
+}
+
+func searchCommandHandler(_ *telego.Bot, tickets storage.TicketStore, inbox *models.AdminInbox, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 {
+			sendText(bot, chatID, "Usage: /search &lt;text&gt;", errorLogger)
+			return
+		}
+
+		filter := &models.InboxFilter{Query: strings.Join(parts[1:], " ")}
+		sendInboxPage(bot, chatID, tickets, inbox, filter, errorLogger)
+	}
+}
+
+func requestCommandHandler(_ *telego.Bot, tickets storage.TicketStore, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 {
+			sendText(bot, chatID, "Usage: /request &lt;id&gt;", errorLogger)
+			return
+		}
+
+		ticket, ok := loadTicket(bot, chatID, tickets, parts[1], errorLogger)
+		if !ok {
+			return
+		}
+
+		message := tu.Message(tu.ID(chatID), utils.FormatTicketDetails(ticket))
+		message.ParseMode = telego.ModeHTML
+		message = message.WithReplyMarkup(markup.GetTicketMarkup(ticket))
+		if _, err := bot.SendMessage(message); err != nil {
+			errorLogger.Println("Failed to send request details:", err)
+		}
+	}
+}
+
+func sendInboxPage(bot *telego.Bot, chatID int64, tickets storage.TicketStore, inbox *models.AdminInbox, filter *models.InboxFilter, errorLogger *log.Logger) {
+	inbox.Lock()
+	inbox.M[chatID] = filter
+	inbox.Unlock()
+
+	text, pageMarkup, err := renderInboxPage(tickets, inbox, chatID, *filter)
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return
+	}
+
+	message := tu.Message(tu.ID(chatID), text)
+	message.ParseMode = telego.ModeHTML
+	message = message.WithReplyMarkup(pageMarkup)
+	if _, err := bot.SendMessage(message); err != nil {
+		errorLogger.Println("Failed to send requests list:", err)
+	}
+}
+
+// renderInboxPage renders the admin's current inbox page. Tickets may have left
+// the filter since the page was opened, so the page is moved back onto the
+// list and stored for the chat.
+func renderInboxPage(tickets storage.TicketStore, inbox *models.AdminInbox, chatID int64, filter models.InboxFilter) (string, *telego.InlineKeyboardMarkup, error) {
+	all, err := tickets.List()
+	if err != nil {
+		return "", nil, err
+	}
+
+	filtered := utils.FilterTickets(all, filter.Status, filter.Query)
+	filter.Page = clampInboxPage(filter.Page, len(filtered))
+
+	inbox.Lock()
+	if stored, ok := inbox.M[chatID]; ok {
+		stored.Page = filter.Page
+	}
+	inbox.Unlock()
+
+	start := filter.Page * inboxPageSize
+	end := min(start+inboxPageSize, len(filtered))
+
+	return utils.FormatTicketListPage(filtered, filter, inboxPageSize), markup.GetInboxMarkup(filtered[start:end]), nil
+}
+
+// clampInboxPage keeps the page within the pages of total tickets.
+func clampInboxPage(page, total int) int {
+	pages := (total + inboxPageSize - 1) / inboxPageSize
+	return max(0, min(page, pages-1))
+}
+
+func handleInboxPagination(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, inbox *models.AdminInbox, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	chatID := query.Message.GetChat().ID
+	isNext := query.Data == "next_requests"
+
+	inbox.RLock()
+	filter, ok := inbox.M[chatID]
+	var current models.InboxFilter
+	if ok {
+		current = *filter
+	}
+	inbox.RUnlock()
+
+	all, err := tickets.List()
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return
+	}
+	total := len(utils.FilterTickets(all, current.Status, current.Query))
+	current.Page = clampInboxPage(current.Page, total)
+
+	if isNext {
+		if current.Page < (total-1)/inboxPageSize {
+			current.Page++
+		} else {
+			return
+		}
+	} else {
+		if current.Page > 0 {
+			current.Page--
+		} else {
+			return
+		}
+	}
+
+	inbox.Lock()
+	inbox.M[chatID] = &current
+	inbox.Unlock()
+
+	renderInboxMessage(bot, tickets, inbox, chatID, current, editedMessage, errorLogger)
+}
+
+func handleInboxBackCallback(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, inbox *models.AdminInbox, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	chatID := query.Message.GetChat().ID
+
+	inbox.RLock()
+	var current models.InboxFilter
+	if filter, ok := inbox.M[chatID]; ok {
+		current = *filter
+	}
+	inbox.RUnlock()
+
+	renderInboxMessage(bot, tickets, inbox, chatID, current, editedMessage, errorLogger)
+}
+
+func renderInboxMessage(bot *telego.Bot, tickets storage.TicketStore, inbox *models.AdminInbox, chatID int64, filter models.InboxFilter, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	text, pageMarkup, err := renderInboxPage(tickets, inbox, chatID, filter)
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return
+	}
+
+	editedMessage.Text = text
+	editedMessage.ReplyMarkup = pageMarkup
+	bot.EditMessageText(&editedMessage)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"log"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+)
+
+const (
+	maxRequestAttachments = 10
+	mediaGroupLimit       = 10
+)
+
+// messageAttachment extracts the document, photo or voice message sent by the user.
+func messageAttachment(message *telego.Message) (models.Attachment, bool) {
+	switch {
+	case message.Document != nil:
+		return models.Attachment{Type: models.AttachmentDocument, FileID: message.Document.FileID, Caption: message.Caption}, true
+	case len(message.Photo) > 0:
+		// Telegram lists photo sizes from the smallest to the largest.
+		largest := message.Photo[len(message.Photo)-1]
+		return models.Attachment{Type: models.AttachmentPhoto, FileID: largest.FileID, Caption: message.Caption}, true
+	case message.Voice != nil:
+		return models.Attachment{Type: models.AttachmentVoice, FileID: message.Voice.FileID, Caption: message.Caption}, true
+	default:
+		return models.Attachment{}, false
+	}
+}
+
+// sessionAttachments reads the attachments collected by the request wizard.
+func sessionAttachments(s *session.Session, key string) []models.Attachment {
+	var attachments []models.Attachment
+	if raw := s.Data[key]; raw != "" {
+		_ = json.Unmarshal([]byte(raw), &attachments)
+	}
+	return attachments
+}
+
+func setSessionAttachments(s *session.Session, key string, attachments []models.Attachment) {
+	raw, err := json.Marshal(attachments)
+	if err != nil {
+		return
+	}
+	s.Data[key] = string(raw)
+}
+
+// sendTicketAttachments sends the ticket's files to the chat as replies to the
+// given message. Photos and documents are grouped into albums, voice messages
+// can't be grouped and are sent one by one.
+func sendTicketAttachments(bot *telego.Bot, ticket *models.Ticket, chatID int64, replyTo int, errorLogger *log.Logger) []models.MessageRef {
+	replyParameters := &telego.ReplyParameters{MessageID: replyTo, AllowSendingWithoutReply: true}
+	refs := make([]models.MessageRef, 0, len(ticket.Attachments))
+
+	var photos, documents []models.Attachment
+	for _, attachment := range ticket.Attachments {
+		switch attachment.Type {
+		case models.AttachmentPhoto:
+			photos = append(photos, attachment)
+		case models.AttachmentDocument:
+			documents = append(documents, attachment)
+		case models.AttachmentVoice:
+			voice := tu.Voice(tu.ID(chatID), tu.FileFromID(attachment.FileID)).
+				WithCaption(html.EscapeString(attachment.Caption)).
+				WithParseMode(telego.ModeHTML).
+				WithReplyParameters(replyParameters)
+			sentMessage, err := bot.SendVoice(voice)
+			if err != nil {
+				errorLogger.Println("Failed to send voice attachment:", err)
+				continue
+			}
+			refs = append(refs, models.MessageRef{ChatID: chatID, MessageID: sentMessage.MessageID})
+		}
+	}
+
+	for _, group := range [][]models.Attachment{photos, documents} {
+		for start := 0; start < len(group); start += mediaGroupLimit {
+			end := min(start+mediaGroupLimit, len(group))
+			refs = append(refs, sendAttachmentGroup(bot, group[start:end], chatID, replyParameters, errorLogger)...)
+		}
+	}
+
+	return refs
+}
+
+func sendAttachmentGroup(bot *telego.Bot, attachments []models.Attachment, chatID int64, replyParameters *telego.ReplyParameters, errorLogger *log.Logger) []models.MessageRef {
+	if len(attachments) == 1 {
+		attachment := attachments[0]
+		caption := html.EscapeString(attachment.Caption)
+
+		var sentMessage *telego.Message
+		var err error
+		if attachment.Type == models.AttachmentPhoto {
+			sentMessage, err = bot.SendPhoto(tu.Photo(tu.ID(chatID), tu.FileFromID(attachment.FileID)).
+				WithCaption(caption).WithParseMode(telego.ModeHTML).WithReplyParameters(replyParameters))
+		} else {
+			sentMessage, err = bot.SendDocument(tu.Document(tu.ID(chatID), tu.FileFromID(attachment.FileID)).
+				WithCaption(caption).WithParseMode(telego.ModeHTML).WithReplyParameters(replyParameters))
+		}
+		if err != nil {
+			errorLogger.Printf("Failed to send %s attachment: %v", attachment.Type, err)
+			return nil
+		}
+		return []models.MessageRef{{ChatID: chatID, MessageID: sentMessage.MessageID}}
+	}
+
+	media := make([]telego.InputMedia, 0, len(attachments))
+	for _, attachment := range attachments {
+		caption := html.EscapeString(attachment.Caption)
+		if attachment.Type == models.AttachmentPhoto {
+			media = append(media, tu.MediaPhoto(tu.FileFromID(attachment.FileID)).WithCaption(caption).WithParseMode(telego.ModeHTML))
+		} else {
+			media = append(media, tu.MediaDocument(tu.FileFromID(attachment.FileID)).WithCaption(caption).WithParseMode(telego.ModeHTML))
+		}
+	}
+
+	sentMessages, err := bot.SendMediaGroup(tu.MediaGroup(tu.ID(chatID), media...).WithReplyParameters(replyParameters))
+	if err != nil {
+		errorLogger.Println("Failed to send attachments:", err)
+		return nil
+	}
+
+	refs := make([]models.MessageRef, 0, len(sentMessages))
+	for _, sentMessage := range sentMessages {
+		refs = append(refs, models.MessageRef{ChatID: chatID, MessageID: sentMessage.MessageID})
+	}
+	return refs
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"log"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+// commandRoles lists the admin commands and the minimal role each one requires.
+var commandRoles = map[string]models.Role{
+	"requests": models.RoleViewer,
+	"request":  models.RoleViewer,
+	"search":   models.RoleViewer,
+	"export":   models.RoleViewer,
+	"stats":    models.RoleViewer,
+	"accept":   models.RoleReviewer,
+	"decline":  models.RoleReviewer,
+	"close":    models.RoleReviewer,
+	"template": models.RoleReviewer,
+	"banned":   models.RoleViewer,
+	"ban":      models.RoleReviewer,
+	"unban":    models.RoleReviewer,
+	"refresh":  models.RoleReviewer,
+	"assign":   models.RoleOwner,
+	"role":     models.RoleOwner,
+	"roles":    models.RoleOwner,
+}
+
+func adminCommand() th.Predicate {
+	predicates := make([]th.Predicate, 0, len(commandRoles))
+	for command := range commandRoles {
+		predicates = append(predicates, th.CommandEqual(command))
+	}
+	return th.Or(predicates...)
+}
+
+// commandAllowed matches admin commands sent by users whose role permits them.
+func commandAllowed(authorizer *auth.Authorizer) th.Predicate {
+	return func(update telego.Update) bool {
+		if update.Message == nil || update.Message.From == nil {
+			return false
+		}
+		role, ok := commandRoles[commandName(update.Message.Text)]
+		return ok && authorizer.Allows(update.Message.From.ID, role)
+	}
+}
+
+// callbackAllowed matches admin callback queries sent by users whose role permits them.
+func callbackAllowed(authorizer *auth.Authorizer) th.Predicate {
+	return func(update telego.Update) bool {
+		if update.CallbackQuery == nil {
+			return false
+		}
+		return authorizer.Allows(update.CallbackQuery.From.ID, callbackRole(update.CallbackQuery.Data))
+	}
+}
+
+// callbackRole returns the role needed for an admin callback: browsing needs a
+// viewer, everything that changes a ticket needs a reviewer.
+func callbackRole(data string) models.Role {
+	if action, _, ok := parseTicketCallback(data); ok && action != "view" {
+		return models.RoleReviewer
+	}
+	return models.RoleViewer
+}
+
+// commandName extracts "accept" from "/accept@bot 12".
+func commandName(text string) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	return name
+}
+
+// canHandleTicket reports whether the user may act on the ticket: claimed tickets
+// are reserved for their assignee and owners.
+func canHandleTicket(authorizer *auth.Authorizer, userID int64, ticket *models.Ticket) bool {
+	if !authorizer.Allows(userID, models.RoleReviewer) {
+		return false
+	}
+	return ticket.AssigneeID == 0 || ticket.AssigneeID == userID || authorizer.Allows(userID, models.RoleOwner)
+}
+
+func userDisplayName(user telego.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return strings.TrimSpace(user.FirstName + " " + user.LastName)
+}
+
+func unauthorizedCommandHandler(_ *telego.Bot, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		from := update.Message.From
+		auditLogger.Printf("Denied command %q from user %d (@%s)", update.Message.Text, from.ID, from.Username)
+
+		sendText(bot, update.Message.Chat.ID, "Sorry, you are not allowed to use this command.", errorLogger)
+	}
+}
+
+func unauthorizedCallbackHandler(_ *telego.Bot, auditLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+	return func(bot *telego.Bot, query telego.CallbackQuery) {
+		auditLogger.Printf("Denied callback %q from user %d (@%s)", query.Data, query.From.ID, query.From.Username)
+
+		answerCallback(bot, query, "Sorry, you are not allowed to do this.")
+	}
+}
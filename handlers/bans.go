@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	th "github.com/mymmrac/telego/telegohandler"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+// banMiddleware drops every update from banned users before any handler sees it.
+// Team members can't be banned, so they always pass.
+func banMiddleware(bans storage.BanStore, authorizer *auth.Authorizer, workLogger *log.Logger) th.Middleware {
+	return func(bot *telego.Bot, update telego.Update, next th.Handler) {
+		userID := updateSenderID(update)
+		if userID == 0 || authorizer.Allows(userID, models.RoleViewer) {
+			next(bot, update)
+			return
+		}
+
+		if _, banned := bans.Get(userID); !banned {
+			next(bot, update)
+			return
+		}
+
+		workLogger.Printf("Dropped update %d from banned user %d", update.UpdateID, userID)
+		if update.CallbackQuery != nil {
+			answerCallback(bot, *update.CallbackQuery, "You are blocked.")
+		}
+
+		// The handler waits for the chain to finish, a cancelled context stops it right away.
+		ctx, cancel := context.WithCancel(update.Context())
+		cancel()
+		next(bot, update.WithContext(ctx))
+	}
+}
+
+func updateSenderID(update telego.Update) int64 {
+	switch {
+	case update.Message != nil && update.Message.From != nil:
+		return update.Message.From.ID
+	case update.EditedMessage != nil && update.EditedMessage.From != nil:
+		return update.EditedMessage.From.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	default:
+		return 0
+	}
+}
+
+// banCommandHandler blocks a user: /ban <user ID> [duration] [reason].
+func banCommandHandler(_ *telego.Bot, bans storage.BanStore, sessions *session.Manager, authorizer *auth.Authorizer, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 {
+			sendText(bot, chatID, "Usage: /ban &lt;user ID&gt; [duration, e.g. 12h or 7d] [reason]", errorLogger)
+			return
+		}
+
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("Invalid user ID: <code>%s</code>", html.EscapeString(parts[1])), errorLogger)
+			return
+		}
+		if authorizer.Allows(userID, models.RoleViewer) {
+			sendText(bot, chatID, "Team members can't be banned, remove their role first.", errorLogger)
+			return
+		}
+
+		now := time.Now()
+		ban := &models.Ban{
+			UserID:    userID,
+			BannedBy:  update.Message.From.ID,
+			CreatedAt: now,
+		}
+
+		reason := parts[2:]
+		if len(reason) > 0 {
+			if duration, err := parseBanDuration(reason[0]); err == nil {
+				expiresAt := now.Add(duration)
+				ban.ExpiresAt = &expiresAt
+				reason = reason[1:]
+			}
+		}
+		ban.Reason = strings.Join(reason, " ")
+
+		if err := bans.Ban(ban); err != nil {
+			errorLogger.Println("Failed to store ban:", err)
+			sendText(bot, chatID, "Failed to ban the user.", errorLogger)
+			return
+		}
+		sessions.End(userID)
+
+		until := "permanently"
+		if expiresAt, ok := ban.Expiry(); ok {
+			until = "until " + expiresAt.Format(time.RFC3339)
+		}
+		auditLogger.Printf("User %d banned user %d %s: %q", ban.BannedBy, userID, until, ban.Reason)
+		sendText(bot, chatID, "Banned:\n\n"+utils.FormatBan(ban), errorLogger)
+	}
+}
+
+func unbanCommandHandler(_ *telego.Bot, bans storage.BanStore, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 {
+			sendText(bot, chatID, "Usage: /unban &lt;user ID&gt;", errorLogger)
+			return
+		}
+
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("Invalid user ID: <code>%s</code>", html.EscapeString(parts[1])), errorLogger)
+			return
+		}
+
+		wasBanned, err := bans.Unban(userID)
+		if err != nil {
+			errorLogger.Println("Failed to remove ban:", err)
+			sendText(bot, chatID, "Failed to unban the user.", errorLogger)
+			return
+		}
+		if !wasBanned {
+			sendText(bot, chatID, fmt.Sprintf("User <code>%d</code> is not banned.", userID), errorLogger)
+			return
+		}
+
+		auditLogger.Printf("User %d unbanned user %d", update.Message.From.ID, userID)
+		sendText(bot, chatID, fmt.Sprintf("User <code>%d</code> is unbanned.", userID), errorLogger)
+	}
+}
+
+func bannedCommandHandler(_ *telego.Bot, bans storage.BanStore, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		sendText(bot, update.Message.Chat.ID, utils.FormatBanList(bans.List()), errorLogger)
+	}
+}
+
+// parseBanDuration accepts Go durations like "12h" and whole days like "7d".
+func parseBanDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count <= 0 {
+			return 0, errors.New("invalid number of days")
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, errors.New("duration must be positive")
+	}
+	return duration, nil
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/scheduler"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const deleteMessageJob = "delete_message"
+
+type deleteMessagePayload struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// messageCleaner deletes bot messages once their kind's TTL has passed.
+type messageCleaner struct {
+	jobs        *scheduler.Scheduler
+	ttl         map[models.MessageKind]time.Duration
+	errorLogger *log.Logger
+}
+
+func newMessageCleaner(jobs *scheduler.Scheduler, ttl map[models.MessageKind]time.Duration, errorLogger *log.Logger) *messageCleaner {
+	jobs.Register(deleteMessageJob, deleteMessage)
+	return &messageCleaner{jobs: jobs, ttl: ttl, errorLogger: errorLogger}
+}
+
+// Notice returns the line telling the user when the message disappears, if it does.
+func (c *messageCleaner) Notice(kind models.MessageKind) string {
+	ttl := c.ttl[kind]
+	if ttl <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThis message will be deleted after <b>%s</b>", utils.FormatTTL(ttl))
+}
+
+func (c *messageCleaner) DeleteLater(kind models.MessageKind, chatID int64, messageID int) {
+	ttl := c.ttl[kind]
+	if ttl <= 0 {
+		return
+	}
+
+	err := c.jobs.Schedule(deleteMessageJob, ttl, deleteMessagePayload{ChatID: chatID, MessageID: messageID})
+	if err != nil {
+		c.errorLogger.Println("Failed to schedule message deletion:", err)
+	}
+}
+
+func deleteMessage(bot *telego.Bot, job *models.Job) error {
+	var payload deleteMessagePayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil
+	}
+
+	err := bot.DeleteMessage(tu.Delete(tu.ID(payload.ChatID), payload.MessageID))
+	// Messages deleted by the user or too old to delete can't be helped by a retry.
+	if err != nil && (strings.Contains(err.Error(), "message to delete not found") ||
+		strings.Contains(err.Error(), "message can't be deleted")) {
+		return nil
+	}
+	return err
+}
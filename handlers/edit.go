@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+// editedMessageHandler applies edits of the requester's answers, either to the
+// request draft or to the pending ticket created from it.
+func editedMessageHandler(_ *telego.Bot, tickets storage.TicketStore, sessions *session.Manager, categories []models.Category, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		message := update.EditedMessage
+		text := message.Text
+		if text == "" {
+			text = message.Caption
+		}
+
+		if handleDraftEdit(bot, message, text, sessions, errorLogger) {
+			return
+		}
+		handleTicketEdit(bot, message, text, tickets, categories, errorLogger)
+	}
+}
+
+func handleDraftEdit(bot *telego.Bot, message *telego.Message, text string, sessions *session.Manager, errorLogger *log.Logger) bool {
+	chatID := message.Chat.ID
+	handled := false
+
+	sessions.Update(chatID, requestFlow, func(s *session.Session) {
+		var steps []models.RequestStep
+		for _, step := range utils.RequestSteps {
+			if s.Int(requestMessageKey(step)) == message.MessageID {
+				steps = append(steps, step)
+			}
+		}
+		if len(steps) == 0 {
+			return
+		}
+		handled = true
+
+		answers, err := editedAnswers(steps, text)
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("<i>%s</i>\n\nThe answer was not changed.", html.EscapeString(err.Error())), errorLogger)
+			return
+		}
+		for step, answer := range answers {
+			s.Data[string(step)] = answer
+		}
+
+		promptID := s.Int(requestKeyPrompt)
+		if models.RequestStep(s.State) != models.StepConfirm || promptID == 0 {
+			return
+		}
+		bot.EditMessageText(&telego.EditMessageTextParams{
+			ChatID:      tu.ID(chatID),
+			MessageID:   promptID,
+			ParseMode:   telego.ModeHTML,
+			Text:        formatRequestSummary(requestFields(s), sessionAttachments(s, requestKeyAttachments)),
+			ReplyMarkup: markup.GetRequestSummaryMarkup(),
+		})
+	})
+
+	return handled
+}
+
+func handleTicketEdit(bot *telego.Bot, message *telego.Message, text string, tickets storage.TicketStore, categories []models.Category, errorLogger *log.Logger) {
+	chatID := message.Chat.ID
+
+	all, err := tickets.List()
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return
+	}
+
+	var ticket *models.Ticket
+	var steps []models.RequestStep
+	for _, candidate := range all {
+		if candidate.ChatID != chatID {
+			continue
+		}
+		if steps = candidate.FieldSteps(message.MessageID); len(steps) > 0 {
+			ticket = candidate
+			break
+		}
+	}
+	if ticket == nil {
+		return
+	}
+
+	answers, err := editedAnswers(steps, text)
+	if err != nil {
+		sendText(bot, chatID, fmt.Sprintf("<i>%s</i>\n\nRequest <b>#%d</b> was not changed.", html.EscapeString(err.Error()), ticket.ID), errorLogger)
+		return
+	}
+
+	status := ticket.Status
+	edited, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		status = stored.Status
+		if stored.Status != models.TicketNew {
+			return errTicketNotPending
+		}
+		for step, answer := range answers {
+			utils.SetRequestField(&stored.Fields, step, answer)
+		}
+		stored.Text = utils.RequestFieldsText(stored.Fields)
+		// The tag follows the new direction, the request stays where it was sent.
+		category, _ := utils.MatchCategory(categories, stored.Fields.Direction)
+		stored.Category = category.Key
+		editedAt := time.Now()
+		stored.EditedAt = &editedAt
+		stored.UpdatedAt = editedAt
+		return nil
+	})
+	if errors.Is(err, errTicketNotPending) {
+		sendText(bot, chatID, fmt.Sprintf("Request <b>#%d</b> is already <b>%s</b>, it can't be changed anymore.", ticket.ID, status), errorLogger)
+		return
+	}
+	if err != nil {
+		errorLogger.Println("Failed to update ticket:", err)
+		return
+	}
+
+	refreshAdminMessages(bot, edited)
+	sendText(bot, chatID, fmt.Sprintf("Request <b>#%d</b> was updated.", edited.ID), errorLogger)
+}
+
+// editedAnswers returns the new answers for the steps the edited message
+// answered, re-parsing it when it held the whole request.
+func editedAnswers(steps []models.RequestStep, text string) (map[models.RequestStep]string, error) {
+	answers := make(map[models.RequestStep]string, len(steps))
+	if len(steps) == 1 {
+		answers[steps[0]] = strings.TrimSpace(text)
+	} else {
+		fields, _ := utils.ParseRequestText(text)
+		for _, step := range steps {
+			answers[step] = strings.TrimSpace(utils.GetRequestField(fields, step))
+		}
+	}
+
+	for step, answer := range answers {
+		if err := utils.ValidateRequestField(step, answer); err != nil {
+			return nil, err
+		}
+	}
+	return answers, nil
+}
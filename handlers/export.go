@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const exportDateLayout = "2006-01-02"
+
+const exportUsage = "Usage: /export &lt;csv|json&gt; [from YYYY-MM-DD] [to YYYY-MM-DD] [status]"
+
+// exportCommandHandler sends the tickets as a file: /export csv 2024-01-01 2024-02-01 accepted.
+// Dates are inclusive, the status and dates may be left out.
+func exportCommandHandler(_ *telego.Bot, tickets storage.TicketStore, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 || (parts[1] != "csv" && parts[1] != "json") {
+			sendText(bot, chatID, exportUsage, errorLogger)
+			return
+		}
+		format := parts[1]
+
+		filter, err := parseExportFilter(parts[2:])
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("<i>%s</i>\n\n%s", html.EscapeString(err.Error()), exportUsage), errorLogger)
+			return
+		}
+
+		all, err := tickets.List()
+		if err != nil {
+			errorLogger.Println("Failed to list tickets:", err)
+			return
+		}
+		selected := utils.FilterExportTickets(all, filter)
+
+		var data []byte
+		if format == "csv" {
+			data, err = utils.ExportTicketsCSV(selected)
+		} else {
+			data, err = utils.ExportTicketsJSON(selected)
+		}
+		if err != nil {
+			errorLogger.Println("Failed to export tickets:", err)
+			sendText(bot, chatID, "Failed to export requests.", errorLogger)
+			return
+		}
+
+		name := fmt.Sprintf("requests-%s.%s", time.Now().Format("2006-01-02-1504"), format)
+		document := tu.Document(tu.ID(chatID), tu.File(tu.NameReader(bytes.NewReader(data), name))).
+			WithCaption(fmt.Sprintf("Exported <b>%d</b> requests.", len(selected))).
+			WithParseMode(telego.ModeHTML)
+		if _, err := bot.SendDocument(document); err != nil {
+			errorLogger.Println("Failed to send export:", err)
+		}
+	}
+}
+
+// parseExportFilter reads the optional arguments of /export: the first date is
+// the start of the range, the second one its end, anything else is a status.
+func parseExportFilter(args []string) (utils.ExportFilter, error) {
+	var filter utils.ExportFilter
+	dates := 0
+
+	for _, arg := range args {
+		if status, ok := models.ParseTicketStatus(arg); ok {
+			filter.Status = status
+			continue
+		}
+
+		date, err := time.ParseInLocation(exportDateLayout, arg, time.Local)
+		if err != nil {
+			return filter, fmt.Errorf("unknown argument %q", arg)
+		}
+		switch dates {
+		case 0:
+			filter.From = date
+		case 1:
+			filter.To = date.AddDate(0, 0, 1)
+		default:
+			return filter, errors.New("too many dates")
+		}
+		dates++
+	}
+
+	if !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return filter, errors.New("the end date is before the start date")
+	}
+	return filter, nil
+}
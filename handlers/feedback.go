@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/scheduler"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const (
+	ticketFeedbackJob = "ticket_feedback"
+
+	feedbackFlow         = "feedback"
+	feedbackStateComment = session.State("comment")
+)
+
+var (
+	errFeedbackNotAsked = errors.New("feedback was not asked for")
+	errFeedbackRated    = errors.New("ticket is already rated")
+)
+
+// ticketFeedback asks requesters to rate how their request was handled some
+// time after the decision, once per ticket.
+type ticketFeedback struct {
+	jobs        *scheduler.Scheduler
+	tickets     storage.TicketStore
+	sessions    *session.Manager
+	after       time.Duration
+	errorLogger *log.Logger
+}
+
+func newTicketFeedback(jobs *scheduler.Scheduler, tickets storage.TicketStore, sessions *session.Manager, after time.Duration, errorLogger *log.Logger) *ticketFeedback {
+	feedback := &ticketFeedback{
+		jobs:        jobs,
+		tickets:     tickets,
+		sessions:    sessions,
+		after:       after,
+		errorLogger: errorLogger,
+	}
+	jobs.Register(ticketFeedbackJob, feedback.ask)
+	sessions.Register(&session.Flow{
+		Name: feedbackFlow,
+		Handlers: map[session.State]session.Handler{
+			feedbackStateComment: feedback.handleComment,
+		},
+	})
+	return feedback
+}
+
+// Schedule plans the feedback question for a ticket the requester got a decision on.
+func (f *ticketFeedback) Schedule(ticketID int64) {
+	if f.after <= 0 {
+		return
+	}
+	if err := f.jobs.Schedule(ticketFeedbackJob, f.after, ticketJobPayload{TicketID: ticketID}); err != nil {
+		f.errorLogger.Println("Failed to schedule feedback question:", err)
+	}
+}
+
+func (f *ticketFeedback) ask(bot *telego.Bot, job *models.Job) error {
+	var payload ticketJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil
+	}
+
+	ticket, err := f.tickets.Get(payload.TicketID)
+	if err != nil || ticket.Feedback != nil {
+		return nil
+	}
+	if ticket.Status != models.TicketDeclined && ticket.Status != models.TicketClosed {
+		return nil
+	}
+
+	message := tu.Message(
+		tu.ID(ticket.ChatID),
+		fmt.Sprintf("How did it go with your request <b>#%d</b>?\n\nPlease rate our interaction, it helps me get better.", ticket.ID),
+	)
+	message.ParseMode = telego.ModeHTML
+	message = message.WithReplyMarkup(markup.GetFeedbackRatingMarkup(ticket.ID))
+	if _, err := bot.SendMessage(message); err != nil {
+		return err
+	}
+
+	// The question is already sent, retrying the job would only repeat it.
+	if _, err := f.tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		if stored.Feedback == nil {
+			stored.Feedback = &models.Feedback{AskedAt: time.Now()}
+		}
+		return nil
+	}); err != nil {
+		f.errorLogger.Println("Failed to store feedback question:", err)
+	}
+	return nil
+}
+
+// feedbackCallbackHandler handles the rating buttons: "feedback_rate:<id>:<rating>"
+// and "feedback_skip:<id>" for leaving no comment.
+func feedbackCallbackHandler(_ *telego.Bot, feedback *ticketFeedback, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+	return func(bot *telego.Bot, query telego.CallbackQuery) {
+		workLogger.Printf("Received feedback callback query from user %d: %s", query.From.ID, query.Data)
+
+		action, rest, _ := strings.Cut(strings.TrimPrefix(query.Data, "feedback_"), ":")
+		rawID, rawRating, _ := strings.Cut(rest, ":")
+		id, err := strconv.ParseInt(rawID, 10, 64)
+		if err != nil {
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+			return
+		}
+
+		switch action {
+		case "rate":
+			rating, err := strconv.Atoi(rawRating)
+			if err != nil || rating < 1 || rating > models.MaxRating {
+				workLogger.Printf("Unknown callback data: %s", query.Data)
+				return
+			}
+			feedback.rate(bot, query, id, rating, errorLogger)
+		case "skip":
+			chatID := query.Message.GetChat().ID
+			feedback.sessions.Update(chatID, feedbackFlow, func(s *session.Session) {
+				if s.Int64(ticketKeyID) == id {
+					s.End()
+				}
+			})
+			_, _ = bot.EditMessageReplyMarkup(&telego.EditMessageReplyMarkupParams{
+				ChatID:    tu.ID(chatID),
+				MessageID: query.Message.GetMessageID(),
+			})
+			answerCallback(bot, query, "Thank you for your feedback!")
+		default:
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+		}
+	}
+}
+
+func (f *ticketFeedback) rate(bot *telego.Bot, query telego.CallbackQuery, id int64, rating int, errorLogger *log.Logger) {
+	chatID := query.Message.GetChat().ID
+
+	rated, err := f.tickets.Modify(id, func(stored *models.Ticket) error {
+		if stored.RequesterID != query.From.ID || stored.Feedback == nil {
+			return errFeedbackNotAsked
+		}
+		if stored.Feedback.Rating != 0 {
+			return errFeedbackRated
+		}
+		stored.Feedback.Rating = rating
+		ratedAt := time.Now()
+		stored.Feedback.RatedAt = &ratedAt
+		return nil
+	})
+	switch {
+	case errors.Is(err, errFeedbackRated):
+		answerCallback(bot, query, fmt.Sprintf("Request #%d is already rated.", id))
+		return
+	case err != nil:
+		if !errors.Is(err, errFeedbackNotAsked) && !errors.Is(err, storage.ErrTicketNotFound) {
+			errorLogger.Println("Failed to store rating:", err)
+		}
+		answerCallback(bot, query, fmt.Sprintf("Request #%d can't be rated.", id))
+		return
+	}
+
+	text := fmt.Sprintf("Thank you for rating request <b>#%d</b>: %s", rated.ID, utils.FormatRating(rating))
+	editedMessage := telego.EditMessageTextParams{
+		ChatID:    tu.ID(chatID),
+		MessageID: query.Message.GetMessageID(),
+		ParseMode: telego.ModeHTML,
+		Text:      text,
+	}
+
+	// Only offer a comment if it can be told apart from a request the user is
+	// filling in right now.
+	if f.sessions.StartIdle(chatID, feedbackFlow, feedbackStateComment, func(s *session.Session) {
+		s.SetInt64(ticketKeyID, rated.ID)
+		s.SetInt(ticketKeyPrompt, query.Message.GetMessageID())
+	}) {
+		editedMessage.Text += "\n\nIf you want to add a comment, send it in the next message."
+		editedMessage.ReplyMarkup = markup.GetFeedbackCommentMarkup(rated.ID)
+	}
+
+	if _, err := bot.EditMessageText(&editedMessage); err != nil {
+		errorLogger.Println("Failed to edit feedback message:", err)
+	}
+	answerCallback(bot, query, "Thank you!")
+}
+
+func (f *ticketFeedback) handleComment(bot *telego.Bot, update telego.Update, s *session.Session) {
+	chatID := update.Message.Chat.ID
+	comment := strings.TrimSpace(update.Message.Text)
+	if comment == "" {
+		sendText(bot, chatID, "Please send your comment as a text message.", f.errorLogger)
+		return
+	}
+	// A reply to anything but the rating message is meant for something else.
+	if reply := update.Message.ReplyToMessage; reply != nil && reply.MessageID != s.Int(ticketKeyPrompt) {
+		sendText(bot, chatID, "Please send your comment as a new message, not as a reply.", f.errorLogger)
+		return
+	}
+
+	s.End()
+	if promptID := s.Int(ticketKeyPrompt); promptID != 0 {
+		_, _ = bot.EditMessageReplyMarkup(&telego.EditMessageReplyMarkupParams{
+			ChatID:    tu.ID(chatID),
+			MessageID: promptID,
+		})
+	}
+
+	if _, err := f.tickets.Modify(s.Int64(ticketKeyID), func(stored *models.Ticket) error {
+		if stored.Feedback == nil {
+			return errFeedbackNotAsked
+		}
+		stored.Feedback.Comment = comment
+		return nil
+	}); err != nil {
+		f.errorLogger.Println("Failed to store feedback comment:", err)
+		return
+	}
+
+	sendText(bot, chatID, "Thank you for your feedback!", f.errorLogger)
+}
+
+func statsCommandHandler(_ *telego.Bot, tickets storage.TicketStore, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		all, err := tickets.List()
+		if err != nil {
+			errorLogger.Println("Failed to list tickets:", err)
+			sendText(bot, update.Message.Chat.ID, "Failed to load the requests.", errorLogger)
+			return
+		}
+
+		sendText(bot, update.Message.Chat.ID, utils.FormatStats(all), errorLogger)
+	}
+}
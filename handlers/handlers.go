@@ -1,9 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"log"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -11,17 +12,85 @@ import (
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/httpclient"
+	"github.com/pureheroky/tg-golang-bot/limits"
 	"github.com/pureheroky/tg-golang-bot/markup"
 	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/scheduler"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
 	"github.com/pureheroky/tg-golang-bot/utils"
 )
 
-func RegisterHandlers(bh *th.BotHandler, bot *telego.Bot, dataStore *models.DataStore, awaitingRequests *models.AwaitingRequests, skillsURL string, errorLogger, workLogger *log.Logger) {
+// Deps holds everything the handlers need from the rest of the bot.
+type Deps struct {
+	DataStore     *models.DataStore
+	Refresher     *utils.DataRefresher
+	Tickets       storage.TicketStore
+	Sessions      *session.Manager
+	Inbox         *models.AdminInbox
+	Authorizer    *auth.Authorizer
+	Limiter       *limits.Limiter
+	Bans          storage.BanStore
+	Templates     storage.TemplateStore
+	Scheduler     *scheduler.Scheduler
+	MessageTTL    map[models.MessageKind]time.Duration
+	ReminderAfter time.Duration
+	FollowUpAfter time.Duration
+	FeedbackAfter time.Duration
+	Categories    []models.Category
+	HTTPClient    *httpclient.Client
+	SkillsURL     string
+	ErrorLogger   *log.Logger
+	WorkLogger    *log.Logger
+	AuditLogger   *log.Logger
+}
+
+func RegisterHandlers(bh *th.BotHandler, bot *telego.Bot, deps *Deps) {
+	dataStore, tickets, sessions, inbox, authorizer := deps.DataStore, deps.Tickets, deps.Sessions, deps.Inbox, deps.Authorizer
+	errorLogger, workLogger := deps.ErrorLogger, deps.WorkLogger
+	cleaner := newMessageCleaner(deps.Scheduler, deps.MessageTTL, errorLogger)
+	reminders := newTicketReminders(deps.Scheduler, tickets, deps.ReminderAfter, deps.FollowUpAfter, errorLogger)
+	feedback := newTicketFeedback(deps.Scheduler, tickets, sessions, deps.FeedbackAfter, errorLogger)
+
+	registerRequestFlow(sessions, deps.Categories, errorLogger)
+	registerTicketFlows(sessions, tickets, cleaner, feedback, errorLogger)
+
+	isAllowed := commandAllowed(authorizer)
+	adminOnly := func(command string) th.Predicate {
+		return th.And(th.CommandEqual(command), isAllowed)
+	}
+
+	bh.Use(banMiddleware(deps.Bans, authorizer, workLogger))
+
 	bh.Handle(startCommandHandler(bot, workLogger), th.CommandEqual("start"))
-	bh.Handle(acceptCommandHandler(bot, errorLogger), th.CommandEqual("accept"))
-	bh.Handle(declineCommandHandler(bot, errorLogger), th.CommandEqual("decline"))
-	bh.HandleCallbackQuery(callbackQueryHandler(bot, dataStore, awaitingRequests, skillsURL, errorLogger, workLogger))
-	bh.Handle(messageHandler(bot, awaitingRequests, errorLogger), th.AnyMessage())
+	bh.Handle(statusCommandHandler(bot, tickets, errorLogger, workLogger), th.CommandEqual("status"))
+	bh.Handle(unauthorizedCommandHandler(bot, errorLogger, deps.AuditLogger), adminCommand(), th.Not(isAllowed))
+	bh.Handle(acceptCommandHandler(bot, tickets, cleaner, authorizer, errorLogger), adminOnly("accept"))
+	bh.Handle(declineCommandHandler(bot, tickets, cleaner, feedback, authorizer, errorLogger), adminOnly("decline"))
+	bh.Handle(closeCommandHandler(bot, tickets, feedback, authorizer, errorLogger), adminOnly("close"))
+	bh.Handle(assignCommandHandler(bot, tickets, authorizer, errorLogger, deps.AuditLogger), adminOnly("assign"))
+	bh.Handle(requestsCommandHandler(bot, tickets, inbox, errorLogger), adminOnly("requests"))
+	bh.Handle(requestCommandHandler(bot, tickets, errorLogger), adminOnly("request"))
+	bh.Handle(searchCommandHandler(bot, tickets, inbox, errorLogger), adminOnly("search"))
+	bh.Handle(exportCommandHandler(bot, tickets, errorLogger), adminOnly("export"))
+	bh.Handle(templateCommandHandler(bot, deps.Templates, errorLogger, deps.AuditLogger), adminOnly("template"))
+	bh.Handle(refreshCommandHandler(bot, deps.Refresher, dataStore, errorLogger, deps.AuditLogger), adminOnly("refresh"))
+	bh.Handle(statsCommandHandler(bot, tickets, errorLogger), adminOnly("stats"))
+	bh.Handle(banCommandHandler(bot, deps.Bans, sessions, authorizer, errorLogger, deps.AuditLogger), adminOnly("ban"))
+	bh.Handle(unbanCommandHandler(bot, deps.Bans, errorLogger, deps.AuditLogger), adminOnly("unban"))
+	bh.Handle(bannedCommandHandler(bot, deps.Bans, errorLogger), adminOnly("banned"))
+	bh.Handle(roleCommandHandler(bot, authorizer, errorLogger, deps.AuditLogger), adminOnly("role"))
+	bh.Handle(rolesCommandHandler(bot, authorizer, errorLogger), adminOnly("roles"))
+	bh.HandleCallbackQuery(unauthorizedCallbackHandler(bot, deps.AuditLogger), adminCallbackPredicate(), th.Not(callbackAllowed(authorizer)))
+	bh.HandleCallbackQuery(adminCallbackHandler(bot, tickets, deps.Templates, cleaner, feedback, sessions, inbox, authorizer, errorLogger, workLogger), adminCallbackPredicate())
+	bh.HandleCallbackQuery(requesterCallbackHandler(bot, tickets, errorLogger, workLogger), th.CallbackDataPrefix("my_ticket_"))
+	bh.HandleCallbackQuery(feedbackCallbackHandler(bot, feedback, errorLogger, workLogger), th.CallbackDataPrefix("feedback_"))
+	bh.HandleCallbackQuery(requestCategoryCallbackHandler(bot, sessions, deps.Categories, errorLogger), th.CallbackDataPrefix("request_category:"))
+	bh.HandleCallbackQuery(callbackQueryHandler(bot, dataStore, tickets, cleaner, reminders, sessions, authorizer, deps.Limiter, deps.Categories, deps.HTTPClient, deps.SkillsURL, errorLogger, workLogger))
+	bh.Handle(messageHandler(bot, tickets, sessions, authorizer, errorLogger), th.AnyMessage())
+	bh.Handle(editedMessageHandler(bot, tickets, sessions, deps.Categories, errorLogger), th.AnyEditedMessage())
 }
 
 func startCommandHandler(_ *telego.Bot, workLogger *log.Logger) func(*telego.Bot, telego.Update) {
@@ -47,7 +116,7 @@ func startCommandHandler(_ *telego.Bot, workLogger *log.Logger) func(*telego.Bot
 	}
 }
 
-func acceptCommandHandler(_ *telego.Bot, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+func acceptCommandHandler(_ *telego.Bot, tickets storage.TicketStore, cleaner *messageCleaner, authorizer *auth.Authorizer, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
 	return func(bot *telego.Bot, update telego.Update) {
 		parts := strings.Fields(update.Message.Text)
 		if len(parts) < 2 {
@@ -55,35 +124,19 @@ func acceptCommandHandler(_ *telego.Bot, errorLogger *log.Logger) func(*telego.B
 			return
 		}
 
-		userId := parts[1]
-		id, err := strconv.ParseInt(userId, 10, 64)
-		if err != nil {
-			errorLogger.Println("Invalid user ID in /accept command:", err)
+		ticket, ok := loadTicketForCommand(bot, update, tickets, authorizer, parts[1], models.TicketAccepted, errorLogger)
+		if !ok {
 			return
 		}
 
-		message := tu.Message(
-			tu.ID(id),
-			"Your request was accepted!\n\nDeveloper will soon contact you\n\nThis message will be deleted after <b>2 minutes</b>",
-		)
-		message.ParseMode = telego.ModeHTML
-
-		sentMessage, err := bot.SendMessage(message)
-		if err != nil {
-			errorLogger.Println("Failed to send acceptance message:", err)
-			return
+		note := strings.Join(parts[2:], " ")
+		if _, err := acceptTicket(bot, tickets, cleaner, ticket.ID, *update.Message.From, note, errorLogger); err != nil {
+			sendText(bot, update.Message.Chat.ID, html.EscapeString(ticketDecisionFailed(err, errorLogger)), errorLogger)
 		}
-
-		time.AfterFunc(2*time.Minute, func() {
-			_ = bot.DeleteMessage(tu.Delete(
-				tu.ID(id),
-				sentMessage.MessageID,
-			))
-		})
 	}
 }
 
-func declineCommandHandler(_ *telego.Bot, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+func declineCommandHandler(_ *telego.Bot, tickets storage.TicketStore, cleaner *messageCleaner, feedback *ticketFeedback, authorizer *auth.Authorizer, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
 	return func(bot *telego.Bot, update telego.Update) {
 		parts := strings.Fields(update.Message.Text)
 		if len(parts) < 2 {
@@ -91,37 +144,91 @@ func declineCommandHandler(_ *telego.Bot, errorLogger *log.Logger) func(*telego.
 			return
 		}
 
-		userId := parts[1]
-		id, err := strconv.ParseInt(userId, 10, 64)
-		if err != nil {
-			errorLogger.Println("Invalid user ID in /decline command:", err)
+		ticket, ok := loadTicketForCommand(bot, update, tickets, authorizer, parts[1], models.TicketDeclined, errorLogger)
+		if !ok {
 			return
 		}
 
 		answer := strings.Join(parts[2:], " ")
+		if _, err := declineTicket(bot, tickets, cleaner, feedback, ticket.ID, answer, errorLogger); err != nil {
+			sendText(bot, update.Message.Chat.ID, html.EscapeString(ticketDecisionFailed(err, errorLogger)), errorLogger)
+		}
+	}
+}
 
-		message := tu.Message(
-			tu.ID(id),
-			fmt.Sprintf("Your request was declined!\n\nDeveloper message: \n%s\n\nThis message will be deleted after <b>2 minutes</b>", answer),
-		)
-		message.ParseMode = telego.ModeHTML
+func closeCommandHandler(_ *telego.Bot, tickets storage.TicketStore, feedback *ticketFeedback, authorizer *auth.Authorizer, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 2 {
+			errorLogger.Println("Invalid /close command format")
+			return
+		}
 
-		sentMessage, err := bot.SendMessage(message)
-		if err != nil {
-			errorLogger.Println("Failed to send decline message:", err)
+		ticket, ok := loadTicketForCommand(bot, update, tickets, authorizer, parts[1], models.TicketClosed, errorLogger)
+		if !ok {
 			return
 		}
 
-		time.AfterFunc(2*time.Minute, func() {
-			_ = bot.DeleteMessage(tu.Delete(
-				tu.ID(id),
-				sentMessage.MessageID,
-			))
-		})
+		if _, err := closeTicket(bot, tickets, feedback, ticket.ID, errorLogger); err != nil {
+			sendText(bot, update.Message.Chat.ID, html.EscapeString(ticketDecisionFailed(err, errorLogger)), errorLogger)
+			return
+		}
+
+		sendText(bot, update.Message.Chat.ID, fmt.Sprintf("Ticket <b>#%d</b> closed.", ticket.ID), errorLogger)
+	}
+}
+
+// loadTicket resolves a ticket ID argument, replying to the chat if it is invalid.
+func loadTicket(bot *telego.Bot, chatID int64, tickets storage.TicketStore, rawID string, errorLogger *log.Logger) (*models.Ticket, bool) {
+	id, err := strconv.ParseInt(strings.TrimPrefix(rawID, "#"), 10, 64)
+	if err != nil {
+		errorLogger.Println("Invalid ticket ID in command:", err)
+		sendText(bot, chatID, fmt.Sprintf("Invalid ticket ID: <code>%s</code>", html.EscapeString(rawID)), errorLogger)
+		return nil, false
+	}
+
+	ticket, err := tickets.Get(id)
+	if err != nil {
+		errorLogger.Printf("Failed to load ticket %d: %v", id, err)
+		sendText(bot, chatID, fmt.Sprintf("Ticket <b>#%d</b> not found.", id), errorLogger)
+		return nil, false
+	}
+
+	return ticket, true
+}
+
+// loadTicketForCommand resolves the ticket ID argument of an admin command and
+// checks that the sender may move the ticket to the target status, replying to the admin otherwise.
+func loadTicketForCommand(bot *telego.Bot, update telego.Update, tickets storage.TicketStore, authorizer *auth.Authorizer, rawID string, target models.TicketStatus, errorLogger *log.Logger) (*models.Ticket, bool) {
+	chatID := update.Message.Chat.ID
+
+	ticket, ok := loadTicket(bot, chatID, tickets, rawID, errorLogger)
+	if !ok {
+		return nil, false
+	}
+
+	if !canHandleTicket(authorizer, update.Message.From.ID, ticket) {
+		sendText(bot, chatID, fmt.Sprintf("Ticket <b>#%d</b> is claimed by %s.", ticket.ID, html.EscapeString(ticket.AssigneeName)), errorLogger)
+		return nil, false
+	}
+
+	if !ticket.CanTransition(target) {
+		sendText(bot, chatID, fmt.Sprintf("Ticket <b>#%d</b> is already <b>%s</b>.", ticket.ID, ticket.Status), errorLogger)
+		return nil, false
+	}
+
+	return ticket, true
+}
+
+func sendText(bot *telego.Bot, chatID int64, text string, errorLogger *log.Logger) {
+	message := tu.Message(tu.ID(chatID), text)
+	message.ParseMode = telego.ModeHTML
+	if _, err := bot.SendMessage(message); err != nil {
+		errorLogger.Println("Failed to send message:", err)
 	}
 }
 
-func callbackQueryHandler(_ *telego.Bot, dataStore *models.DataStore, awaitingRequests *models.AwaitingRequests, skillsURL string, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+func callbackQueryHandler(_ *telego.Bot, dataStore *models.DataStore, tickets storage.TicketStore, cleaner *messageCleaner, reminders *ticketReminders, sessions *session.Manager, authorizer *auth.Authorizer, limiter *limits.Limiter, categories []models.Category, httpClient *httpclient.Client, skillsURL string, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
 	return func(bot *telego.Bot, query telego.CallbackQuery) {
 		workLogger.Printf("Received callback query from user %d: %s", query.From.ID, query.Data)
 
@@ -143,9 +250,21 @@ func callbackQueryHandler(_ *telego.Bot, dataStore *models.DataStore, awaitingRe
 
 		switch query.Data {
 		case "request":
-			handleRequestCallback(bot, query, BackMarkup, awaitingRequests, editedMessage)
+			handleRequestCallback(bot, query, sessions, limiter, authorizer, editedMessage, errorLogger)
+		case "request_summary":
+			handleRequestSummaryCallback(bot, query, sessions, editedMessage)
+		case "request_edit":
+			handleRequestEditCallback(bot, query, sessions, editedMessage)
+		case "request_edit_name", "request_edit_direction", "request_edit_description", "request_edit_contact":
+			handleRequestEditFieldCallback(bot, query, sessions, categories, editedMessage)
+		case "request_confirm":
+			handleRequestConfirmCallback(bot, query, tickets, cleaner, reminders, sessions, limiter, authorizer, categories, editedMessage, errorLogger)
+		case "request_cancel":
+			handleRequestCancelCallback(bot, query, sessions, editedMessage)
+		case "my_requests":
+			handleMyRequestsCallback(bot, query, tickets, editedMessage, errorLogger)
 		case "skills":
-			handleSkillsCallback(bot, query, skillsURL, BackMarkup, editedMessage, errorLogger)
+			handleSkillsCallback(bot, query, httpClient, skillsURL, BackMarkup, editedMessage, errorLogger)
 		case "git":
 			handleGitCallback(bot, query, dataStore, gitMarkup, pageSize, editedMessage, errorLogger)
 		case "projects":
@@ -155,24 +274,29 @@ func callbackQueryHandler(_ *telego.Bot, dataStore *models.DataStore, awaitingRe
 		case "next_project", "previous_project":
 			handleProjectPagination(bot, query, dataStore, projectMarkup, editedMessage)
 		case "back":
-			handleBackCallback(bot, query, awaitingRequests, editedMessage)
+			handleBackCallback(bot, query, sessions, editedMessage)
 		default:
 			workLogger.Printf("Unknown callback data: %s", query.Data)
 		}
 	}
 }
 
-func messageHandler(_ *telego.Bot, awaitingRequests *models.AwaitingRequests, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+func messageHandler(_ *telego.Bot, tickets storage.TicketStore, sessions *session.Manager, authorizer *auth.Authorizer, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
 	return func(bot *telego.Bot, update telego.Update) {
 		chatID := update.Message.Chat.ID
 
-		awaitingRequests.RLock()
-		awaiting, ok := awaitingRequests.M[chatID]
-		awaitingRequests.RUnlock()
+		if ticketID, ok := repliedTicket(update.Message, tickets, sessions); ok {
+			// Replying to the question answered it already.
+			sessions.Update(chatID, answerFlow, func(s *session.Session) {
+				if s.Int64(ticketKeyID) == ticketID {
+					s.End()
+				}
+			})
+		} else if sessions.Handle(bot, update) {
+			return
+		}
 
-		if ok && awaiting {
-			handleRequestMessage(bot, update, awaitingRequests, errorLogger)
-		} else {
+		if !handleRelayMessage(bot, update, tickets, authorizer, errorLogger) {
 			_ = bot.DeleteMessage(tu.Delete(
 				tu.ID(chatID),
 				update.Message.MessageID,
@@ -181,35 +305,35 @@ func messageHandler(_ *telego.Bot, awaitingRequests *models.AwaitingRequests, er
 	}
 }
 
-func handleRequestCallback(bot *telego.Bot, query telego.CallbackQuery, requestMarkup *telego.InlineKeyboardMarkup, awaitingRequests *models.AwaitingRequests, editedMessage telego.EditMessageTextParams) {
-	messageText := `
-You are on <b>Request</b> page.
-
-If you want to make a job request, please follow the <b>sample</b>:
-
-<b>1. Your name</b>
-<b>2. Direction of the task (any web-development/python apps etc.)</b>
-<b>3. Task description</b>
-<b>4. Ways to contact you</b>
-
-Requests not similar to the sample <span class='tg-spoiler'><b>will be ignored.</b></span>
-`
-	editedMessage.Text = messageText
-	editedMessage.ReplyMarkup = requestMarkup
-	bot.EditMessageText(&editedMessage)
-
-	chatID := query.Message.GetChat().ID
-	awaitingRequests.Lock()
-	awaitingRequests.M[chatID] = true
-	awaitingRequests.Unlock()
+// repliedTicket returns the ticket whose conversation the message replies to
+// while the chat is in a flow the bot started on its own. Such a reply is meant
+// for the conversation, not for the flow.
+func repliedTicket(message *telego.Message, tickets storage.TicketStore, sessions *session.Manager) (int64, bool) {
+	if message.ReplyToMessage == nil {
+		return 0, false
+	}
+	if flow, _, ok := sessions.Active(message.Chat.ID); !ok || (flow != answerFlow && flow != feedbackFlow) {
+		return 0, false
+	}
+	ticket, err := tickets.FindByMessage(message.Chat.ID, message.ReplyToMessage.MessageID)
+	if err != nil {
+		return 0, false
+	}
+	return ticket.ID, true
 }
 
-func handleSkillsCallback(bot *telego.Bot, _ telego.CallbackQuery, skillsURL string, skillsMarkup *telego.InlineKeyboardMarkup, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+// skillsLoadTimeout bounds loading the skills page, retries included.
+const skillsLoadTimeout = 30 * time.Second
+
+func handleSkillsCallback(bot *telego.Bot, _ telego.CallbackQuery, httpClient *httpclient.Client, skillsURL string, skillsMarkup *telego.InlineKeyboardMarkup, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
 	messageText := "You are on <b>Skills</b> page\nAll my knowledge will be shown here\n\n\n<b><i>Loading skills...</i></b>"
 	editedMessage.Text = messageText
 	bot.EditMessageText(&editedMessage)
 
-	skills, err := utils.GetSkills(skillsURL)
+	ctx, cancel := context.WithTimeout(context.Background(), skillsLoadTimeout)
+	defer cancel()
+
+	skills, err := utils.GetSkills(ctx, httpClient, skillsURL)
 	if err != nil {
 		errorLogger.Println("Failed to get skills:", err)
 		editedMessage.Text = "Failed to load skills."
@@ -344,69 +468,43 @@ func handleProjectPagination(bot *telego.Bot, query telego.CallbackQuery, dataSt
 	projects := dataStore.Projects
 	dataStore.Unlock()
 
+	// A refresh may have swapped in fewer projects in the meantime.
+	if currentIndex >= len(projects) {
+		return
+	}
+
 	messageText := utils.FormatProjectMessage(projects[currentIndex])
 	editedMessage.ReplyMarkup = projectMarkup
 	editedMessage.Text = messageText
 	bot.EditMessageText(&editedMessage)
 }
 
-func handleBackCallback(bot *telego.Bot, query telego.CallbackQuery, awaitingRequests *models.AwaitingRequests, editedMessage telego.EditMessageTextParams) {
+func handleBackCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, editedMessage telego.EditMessageTextParams) {
 	messageText := utils.GetWelcomeMessage()
 	editedMessage.Text = messageText
 	editedMessage.ReplyMarkup = markup.GetMainMenuMarkup()
 	bot.EditMessageText(&editedMessage)
 
 	chatID := query.Message.GetChat().ID
-	awaitingRequests.Lock()
-	delete(awaitingRequests.M, chatID)
-	awaitingRequests.Unlock()
+	sessions.End(chatID)
 }
 
-func handleRequestMessage(bot *telego.Bot, update telego.Update, awaitingRequests *models.AwaitingRequests, errorLogger *log.Logger) {
-	chatID := update.Message.Chat.ID
-	requestText := update.Message.Text
-	requestID := update.Message.From.ID
-	requestUsername := update.Message.From.Username
-
-	awaitingRequests.Lock()
-	delete(awaitingRequests.M, chatID)
-	awaitingRequests.Unlock()
-
-	adminID, err := strconv.ParseInt(os.Getenv("USER_ID"), 10, 64)
-	if err != nil {
-		errorLogger.Println("Invalid admin USER_ID:", err)
-		return
-	}
+// refreshCommandHandler fetches the GitHub projects and commits right away.
+func refreshCommandHandler(_ *telego.Bot, refresher *utils.DataRefresher, dataStore *models.DataStore, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		auditLogger.Printf("User %d refreshed the GitHub data", update.Message.From.ID)
+		sendText(bot, chatID, "Refreshing GitHub data...", errorLogger)
 
-	messageToAdmin := tu.Message(
-		tu.ID(adminID),
-		fmt.Sprintf("Request from <code>%s</code> | <code>%d</code>\n\n%s", requestUsername, requestID, requestText),
-	)
-	messageToAdmin.ParseMode = telego.ModeHTML
-	if _, err := bot.SendMessage(messageToAdmin); err != nil {
-		errorLogger.Println("Failed to send request message to admin:", err)
-	}
+		if err := refresher.Refresh(update.Context()); err != nil {
+			sendText(bot, chatID, fmt.Sprintf("Failed to refresh GitHub data: <code>%s</code>\n\nThe previous data is kept.", html.EscapeString(err.Error())), errorLogger)
+			return
+		}
 
-	message := tu.Message(
-		tu.ID(chatID),
-		"Thank you for your job request.\n\nYour and this message will be deleted after <b>2 minutes</b>\n\nI'll write you after reviewing your request",
-	)
-	message.ParseMode = telego.ModeHTML
+		dataStore.RLock()
+		projects, repositories := len(dataStore.Projects), len(dataStore.Git)
+		dataStore.RUnlock()
 
-	sentMessage, err := bot.SendMessage(message)
-	if err != nil {
-		errorLogger.Println("Failed to send confirmation message:", err)
-		return
+		sendText(bot, chatID, fmt.Sprintf("GitHub data refreshed: <b>%d</b> projects, commits of <b>%d</b> repositories.", projects, repositories), errorLogger)
 	}
-
-	time.AfterFunc(2*time.Minute, func() {
-		_ = bot.DeleteMessage(tu.Delete(
-			tu.ID(chatID),
-			sentMessage.MessageID,
-		))
-		_ = bot.DeleteMessage(tu.Delete(
-			tu.ID(chatID),
-			update.Message.MessageID,
-		))
-	})
 }
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+)
+
+// handleRelayMessage passes messages between the admin and the requester of an
+// accepted ticket. Admins reply to a message of the ticket, requesters either
+// reply to a relayed message or just write to the bot. Requesters of pending
+// tickets may only reply, to answer a question of the admins. It reports
+// whether the message belonged to a ticket conversation.
+func handleRelayMessage(bot *telego.Bot, update telego.Update, tickets storage.TicketStore, authorizer *auth.Authorizer, errorLogger *log.Logger) bool {
+	message := update.Message
+	chatID := message.Chat.ID
+
+	var ticket *models.Ticket
+	toRequester := false
+	replied := false
+
+	if reply := message.ReplyToMessage; reply != nil {
+		if found, err := tickets.FindByMessage(chatID, reply.MessageID); err == nil {
+			ticket = found
+			toRequester = found.HasAdminMessage(chatID, reply.MessageID) || chatID != found.ChatID
+			replied = true
+		}
+	}
+
+	if ticket == nil {
+		ticket = latestAcceptedTicket(tickets, chatID, errorLogger)
+		if ticket == nil {
+			return false
+		}
+	}
+
+	if toRequester && (message.From == nil || !canHandleTicket(authorizer, message.From.ID, ticket)) {
+		return false
+	}
+
+	answersQuestion := replied && !toRequester && ticket.Status == models.TicketNew
+	if ticket.Status != models.TicketAccepted && !answersQuestion {
+		if !toRequester {
+			return false
+		}
+		sendText(bot, chatID, fmt.Sprintf("Request <b>#%d</b> is <b>%s</b>, messages are relayed only for accepted requests.", ticket.ID, ticket.Status), errorLogger)
+		return true
+	}
+
+	var refs []models.MessageRef
+	if toRequester {
+		refs = relayToRequester(bot, message, ticket, errorLogger)
+	} else {
+		refs = relayToAdmins(bot, message, ticket, errorLogger)
+	}
+
+	if len(refs) == 0 {
+		return true
+	}
+
+	_, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		stored.RelayMessages = append(stored.RelayMessages, refs...)
+		return nil
+	})
+	if err != nil {
+		errorLogger.Println("Failed to store relayed messages:", err)
+	}
+
+	return true
+}
+
+func latestAcceptedTicket(tickets storage.TicketStore, chatID int64, errorLogger *log.Logger) *models.Ticket {
+	all, err := tickets.List()
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return nil
+	}
+
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].ChatID == chatID && all[i].Status == models.TicketAccepted {
+			return all[i]
+		}
+	}
+	return nil
+}
+
+func relayToRequester(bot *telego.Bot, message *telego.Message, ticket *models.Ticket, errorLogger *log.Logger) []models.MessageRef {
+	messageID, err := relayMessage(bot, message, ticket.ChatID, 0,
+		fmt.Sprintf("<b>Developer</b> | request <b>#%d</b>:\n\n", ticket.ID))
+	if err != nil {
+		errorLogger.Println("Failed to relay message to requester:", err)
+		sendText(bot, message.Chat.ID, fmt.Sprintf("Failed to deliver the message for request <b>#%d</b>.", ticket.ID), errorLogger)
+		return nil
+	}
+
+	return []models.MessageRef{{ChatID: ticket.ChatID, MessageID: messageID}}
+}
+
+func relayToAdmins(bot *telego.Bot, message *telego.Message, ticket *models.Ticket, errorLogger *log.Logger) []models.MessageRef {
+	refs := make([]models.MessageRef, 0, len(ticket.AdminMessages))
+
+	for _, root := range ticket.AdminMessages {
+		messageID, err := relayMessage(bot, message, root.ChatID, root.MessageID,
+			fmt.Sprintf("<code>%s</code> | request <b>#%d</b>:\n\n", html.EscapeString(ticket.Username), ticket.ID))
+		if err != nil {
+			errorLogger.Println("Failed to relay message to admin:", err)
+			continue
+		}
+		refs = append(refs, models.MessageRef{ChatID: root.ChatID, MessageID: messageID})
+	}
+
+	return refs
+}
+
+// relayMessage delivers a message on behalf of the bot, so neither side sees
+// who sent it. Text gets a header naming the ticket, media is copied as is.
+func relayMessage(bot *telego.Bot, message *telego.Message, toChatID int64, replyTo int, header string) (int, error) {
+	var replyParameters *telego.ReplyParameters
+	if replyTo != 0 {
+		replyParameters = &telego.ReplyParameters{MessageID: replyTo, AllowSendingWithoutReply: true}
+	}
+
+	if message.Text != "" {
+		relayed := tu.Message(tu.ID(toChatID), header+html.EscapeString(message.Text))
+		relayed.ParseMode = telego.ModeHTML
+		relayed.ReplyParameters = replyParameters
+
+		sentMessage, err := bot.SendMessage(relayed)
+		if err != nil {
+			return 0, err
+		}
+		return sentMessage.MessageID, nil
+	}
+
+	copied := tu.CopyMessage(tu.ID(toChatID), tu.ID(message.Chat.ID), message.MessageID)
+	copied.ReplyParameters = replyParameters
+
+	copiedID, err := bot.CopyMessage(copied)
+	if err != nil {
+		return 0, err
+	}
+	return copiedID.MessageID, nil
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/scheduler"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const (
+	ticketReminderJob = "ticket_reminder"
+	ticketFollowUpJob = "ticket_followup"
+)
+
+var errNoReminderTarget = errors.New("ticket has no admin to remind")
+
+type ticketJobPayload struct {
+	TicketID int64 `json:"ticket_id"`
+}
+
+// ticketReminders nags admins about requests left without an answer and
+// reassures their requesters. Both only fire while the ticket is still new.
+type ticketReminders struct {
+	jobs          *scheduler.Scheduler
+	tickets       storage.TicketStore
+	reminderAfter time.Duration
+	followUpAfter time.Duration
+	errorLogger   *log.Logger
+}
+
+func newTicketReminders(jobs *scheduler.Scheduler, tickets storage.TicketStore, reminderAfter, followUpAfter time.Duration, errorLogger *log.Logger) *ticketReminders {
+	reminders := &ticketReminders{
+		jobs:          jobs,
+		tickets:       tickets,
+		reminderAfter: reminderAfter,
+		followUpAfter: followUpAfter,
+		errorLogger:   errorLogger,
+	}
+	jobs.Register(ticketReminderJob, reminders.remindAdmins)
+	jobs.Register(ticketFollowUpJob, reminders.followUp)
+	return reminders
+}
+
+// Schedule plans the reminders of a new ticket, a zero threshold disables one.
+func (r *ticketReminders) Schedule(ticketID int64) {
+	payload := ticketJobPayload{TicketID: ticketID}
+
+	if r.reminderAfter > 0 {
+		if err := r.jobs.Schedule(ticketReminderJob, r.reminderAfter, payload); err != nil {
+			r.errorLogger.Println("Failed to schedule ticket reminder:", err)
+		}
+	}
+	if r.followUpAfter > 0 {
+		if err := r.jobs.Schedule(ticketFollowUpJob, r.followUpAfter, payload); err != nil {
+			r.errorLogger.Println("Failed to schedule ticket follow-up:", err)
+		}
+	}
+}
+
+// pendingTicket returns the ticket of the job if it still waits for an answer.
+func (r *ticketReminders) pendingTicket(job *models.Job) (*models.Ticket, bool) {
+	var payload ticketJobPayload
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return nil, false
+	}
+
+	ticket, err := r.tickets.Get(payload.TicketID)
+	if err != nil || ticket.Status != models.TicketNew {
+		return nil, false
+	}
+	return ticket, true
+}
+
+// remindAdmins replies to the admin copies of the ticket, only to the assignee's
+// copy once somebody claimed it. An assignee without a copy of their own, as
+// the request went to a category chat, is reminded in a direct message.
+func (r *ticketReminders) remindAdmins(bot *telego.Bot, job *models.Job) error {
+	ticket, ok := r.pendingTicket(job)
+	if !ok {
+		return nil
+	}
+
+	text := fmt.Sprintf("Request <b>#%d</b> has been waiting for an answer for <b>%s</b>.", ticket.ID, utils.FormatTTL(time.Since(ticket.CreatedAt).Round(time.Minute)))
+
+	targets := ticket.AdminMessages
+	if ticket.AssigneeID != 0 {
+		targets = []models.MessageRef{{ChatID: ticket.AssigneeID}}
+		for _, ref := range ticket.AdminMessages {
+			if ref.ChatID == ticket.AssigneeID {
+				targets[0] = ref
+				break
+			}
+		}
+	}
+
+	var lastErr error
+	sent := 0
+	for _, ref := range targets {
+		message := tu.Message(tu.ID(ref.ChatID), text)
+		message.ParseMode = telego.ModeHTML
+		if ref.MessageID != 0 {
+			message = message.WithReplyParameters(&telego.ReplyParameters{
+				MessageID:                ref.MessageID,
+				AllowSendingWithoutReply: true,
+			})
+		}
+		if _, err := bot.SendMessage(message); err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+
+	if sent == 0 {
+		if lastErr == nil {
+			lastErr = errNoReminderTarget
+		}
+		return lastErr
+	}
+	return nil
+}
+
+func (r *ticketReminders) followUp(bot *telego.Bot, job *models.Job) error {
+	ticket, ok := r.pendingTicket(job)
+	if !ok {
+		return nil
+	}
+
+	message := tu.Message(
+		tu.ID(ticket.ChatID),
+		fmt.Sprintf("Your request <b>#%d</b> is still being reviewed. Thank you for your patience, I'll write you as soon as possible.", ticket.ID),
+	)
+	message.ParseMode = telego.ModeHTML
+	_, err := bot.SendMessage(message)
+	return err
+}
@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/limits"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const requestFlow = "request"
+
+const (
+	requestKeyPrompt      = "prompt_message_id"
+	requestKeyEditing     = "editing"
+	requestKeyAttachments = "attachments"
+	requestKeyMediaGroup  = "media_group"
+)
+
+var requestEditSteps = map[string]models.RequestStep{
+	"request_edit_name":        models.StepName,
+	"request_edit_direction":   models.StepDirection,
+	"request_edit_description": models.StepDescription,
+	"request_edit_contact":     models.StepContact,
+}
+
+func registerRequestFlow(sessions *session.Manager, categories []models.Category, errorLogger *log.Logger) {
+	handler := func(bot *telego.Bot, update telego.Update, s *session.Session) {
+		handleRequestMessage(bot, update, s, categories, errorLogger)
+	}
+
+	sessions.Register(&session.Flow{
+		Name: requestFlow,
+		Handlers: map[session.State]session.Handler{
+			session.State(models.StepName):        handler,
+			session.State(models.StepDirection):   handler,
+			session.State(models.StepDescription): handler,
+			session.State(models.StepContact):     handler,
+		},
+		OnExpire: func(bot *telego.Bot, s *session.Session) {
+			if promptID := s.Int(requestKeyPrompt); promptID != 0 {
+				bot.EditMessageText(&telego.EditMessageTextParams{
+					ChatID:    tu.ID(s.ChatID),
+					MessageID: promptID,
+					ParseMode: telego.ModeHTML,
+					Text:      "Your request draft has expired.\n\nUse /start to begin again.",
+				})
+			}
+		},
+	})
+}
+
+func handleRequestCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, limiter *limits.Limiter, authorizer *auth.Authorizer, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	if denial := checkRequestLimits(query.From.ID, limiter, authorizer, errorLogger); denial != nil {
+		editedMessage.Text = utils.FormatLimitDenial(denial, time.Now())
+		editedMessage.ReplyMarkup = markup.GetBackMarkup()
+		bot.EditMessageText(&editedMessage)
+		return
+	}
+
+	messageText := `
+You are on <b>Request</b> page.
+
+To make a job request, answer <b>4 short questions</b>. You will be able to review and edit your answers before sending.
+
+You can also send the whole request in one message:
+<code>1. Name
+2. Direction of the task
+3. Description
+4. Contact</code>
+
+` + utils.GetRequestStepPrompt(models.StepName)
+
+	editedMessage.Text = messageText
+	editedMessage.ReplyMarkup = markup.GetRequestStepMarkup(models.StepName, nil)
+	bot.EditMessageText(&editedMessage)
+
+	chatID := query.Message.GetChat().ID
+	sessions.Start(chatID, requestFlow, session.State(models.StepName), func(s *session.Session) {
+		s.SetInt(requestKeyPrompt, query.Message.GetMessageID())
+	})
+}
+
+// checkRequestLimits returns why the user can't send a request now, if so.
+// Team members are never limited.
+func checkRequestLimits(userID int64, limiter *limits.Limiter, authorizer *auth.Authorizer, errorLogger *log.Logger) *limits.Denial {
+	if authorizer.Allows(userID, models.RoleViewer) {
+		return nil
+	}
+
+	denial, err := limiter.Check(userID, time.Now())
+	if err != nil {
+		errorLogger.Println("Failed to check request limits:", err)
+		return nil
+	}
+	return denial
+}
+
+func handleRequestMessage(bot *telego.Bot, update telego.Update, s *session.Session, categories []models.Category, errorLogger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	answer := update.Message.Text
+	step := models.RequestStep(s.State)
+
+	if attachment, ok := messageAttachment(update.Message); ok {
+		if !handleRequestAttachment(bot, update.Message, s, attachment, categories, errorLogger) {
+			return
+		}
+		answer = attachment.Caption
+	}
+
+	if step == models.StepName && s.Data[requestKeyEditing] == "" && strings.Contains(answer, "\n") {
+		if fields, found := utils.ParseRequestText(answer); len(found) > 1 {
+			handleParsedRequest(bot, chatID, s, fields, found, update.Message.MessageID, categories, errorLogger)
+			return
+		}
+	}
+
+	if answer == "" {
+		sendRequestPrompt(bot, chatID, s, "Please answer with a text message.\n\n"+utils.GetRequestStepPrompt(step), markup.GetRequestStepMarkup(step, categories), errorLogger)
+		return
+	}
+
+	if err := utils.ValidateRequestField(step, answer); err != nil {
+		sendRequestPrompt(bot, chatID, s, fmt.Sprintf("<i>%s</i>\n\n%s", html.EscapeString(err.Error()), utils.GetRequestStepPrompt(step)), markup.GetRequestStepMarkup(step, categories), errorLogger)
+		return
+	}
+
+	s.Data[string(step)] = strings.TrimSpace(answer)
+	s.SetInt(requestMessageKey(step), update.Message.MessageID)
+
+	next := nextRequestStep(s)
+	if s.Data[requestKeyEditing] != "" {
+		next = models.StepConfirm
+		delete(s.Data, requestKeyEditing)
+	}
+	s.Transition(session.State(next))
+
+	if next == models.StepConfirm {
+		sendRequestPrompt(bot, chatID, s, formatRequestSummary(requestFields(s), sessionAttachments(s, requestKeyAttachments)), markup.GetRequestSummaryMarkup(), errorLogger)
+		return
+	}
+	sendRequestPrompt(bot, chatID, s, utils.GetRequestStepPrompt(next), markup.GetRequestStepMarkup(next, categories), errorLogger)
+}
+
+// handleParsedRequest fills the draft from a whole request pasted in one message
+// and asks only for the answers that are missing or invalid.
+func handleParsedRequest(bot *telego.Bot, chatID int64, s *session.Session, fields models.RequestFields, found []models.RequestStep, messageID int, categories []models.Category, errorLogger *log.Logger) {
+	filled := 0
+	problems := ""
+	for _, step := range found {
+		value := utils.GetRequestField(fields, step)
+		if err := utils.ValidateRequestField(step, value); err != nil {
+			problems += fmt.Sprintf("<i>%s</i>\n", html.EscapeString(err.Error()))
+			continue
+		}
+		s.Data[string(step)] = strings.TrimSpace(value)
+		s.SetInt(requestMessageKey(step), messageID)
+		filled++
+	}
+
+	next := nextRequestStep(s)
+	s.Transition(session.State(next))
+
+	if next == models.StepConfirm {
+		sendRequestPrompt(bot, chatID, s, formatRequestSummary(requestFields(s), sessionAttachments(s, requestKeyAttachments)), markup.GetRequestSummaryMarkup(), errorLogger)
+		return
+	}
+
+	text := fmt.Sprintf("I've taken <b>%d of %d</b> answers from your message.\n", filled, len(utils.RequestSteps))
+	if problems != "" {
+		text += "\n" + problems
+	}
+	sendRequestPrompt(bot, chatID, s, text+"\n"+utils.GetRequestStepPrompt(next), markup.GetRequestStepMarkup(next, categories), errorLogger)
+}
+
+// nextRequestStep returns the first step the draft has no answer for yet.
+func nextRequestStep(s *session.Session) models.RequestStep {
+	for _, step := range utils.RequestSteps {
+		if s.Data[string(step)] == "" {
+			return step
+		}
+	}
+	return models.StepConfirm
+}
+
+// handleRequestAttachment collects a file sent while describing the task. Files
+// of an album arrive as separate messages, the ones following the first are
+// added silently even if the caption of the first one already moved the wizard
+// on. It reports whether the caption should be handled as the answer.
+func handleRequestAttachment(bot *telego.Bot, message *telego.Message, s *session.Session, attachment models.Attachment, categories []models.Category, errorLogger *log.Logger) bool {
+	chatID := message.Chat.ID
+	step := models.RequestStep(s.State)
+	sameAlbum := message.MediaGroupID != "" && message.MediaGroupID == s.Data[requestKeyMediaGroup]
+
+	if step != models.StepDescription && !sameAlbum {
+		sendRequestPrompt(bot, chatID, s, "Files can be attached only to the task description.\n\n"+utils.GetRequestStepPrompt(step), markup.GetRequestStepMarkup(step, categories), errorLogger)
+		return false
+	}
+
+	attachments := sessionAttachments(s, requestKeyAttachments)
+	if len(attachments) >= maxRequestAttachments {
+		if !sameAlbum {
+			sendRequestPrompt(bot, chatID, s, fmt.Sprintf("You can attach up to <b>%d</b> files.\n\n%s", maxRequestAttachments, utils.GetRequestStepPrompt(step)), markup.GetRequestStepMarkup(step, categories), errorLogger)
+		}
+		return false
+	}
+
+	setSessionAttachments(s, requestKeyAttachments, append(attachments, attachment))
+	s.Data[requestKeyMediaGroup] = message.MediaGroupID
+
+	if sameAlbum || step != models.StepDescription {
+		return false
+	}
+	if attachment.Caption != "" {
+		return true
+	}
+
+	sendRequestPrompt(bot, chatID, s, fmt.Sprintf("Attached: <b>%s</b>.\n\nSend more files or describe the task in a text message.", utils.FormatAttachments(sessionAttachments(s, requestKeyAttachments))), markup.GetRequestStepMarkup(step, categories), errorLogger)
+	return false
+}
+
+// sendRequestPrompt replaces the previous wizard message with a new one below the user's answer.
+func sendRequestPrompt(bot *telego.Bot, chatID int64, s *session.Session, text string, replyMarkup *telego.InlineKeyboardMarkup, errorLogger *log.Logger) {
+	if promptID := s.Int(requestKeyPrompt); promptID != 0 {
+		_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), promptID))
+	}
+
+	message := tu.Message(tu.ID(chatID), text)
+	message.ParseMode = telego.ModeHTML
+	message = message.WithReplyMarkup(replyMarkup)
+
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		errorLogger.Println("Failed to send request prompt:", err)
+		s.SetInt(requestKeyPrompt, 0)
+		return
+	}
+	s.SetInt(requestKeyPrompt, sentMessage.MessageID)
+}
+
+// requestMessageKey is the session key of the message that answered the step.
+func requestMessageKey(step models.RequestStep) string {
+	return "message_" + string(step)
+}
+
+func requestFieldMessages(s *session.Session) map[models.RequestStep]int {
+	messages := make(map[models.RequestStep]int)
+	for _, step := range utils.RequestSteps {
+		if messageID := s.Int(requestMessageKey(step)); messageID != 0 {
+			messages[step] = messageID
+		}
+	}
+	return messages
+}
+
+func requestFields(s *session.Session) models.RequestFields {
+	return models.RequestFields{
+		Name:        s.Data[string(models.StepName)],
+		Direction:   s.Data[string(models.StepDirection)],
+		Description: s.Data[string(models.StepDescription)],
+		Contact:     s.Data[string(models.StepContact)],
+	}
+}
+
+func formatRequestSummary(fields models.RequestFields, attachments []models.Attachment) string {
+	summary := "Please check your request:\n\n" + utils.FormatRequestFields(fields)
+	if len(attachments) > 0 {
+		summary += fmt.Sprintf("\n<b>Attachments:</b> %s", utils.FormatAttachments(attachments))
+	}
+	return summary
+}
+
+func handleRequestSummaryCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, editedMessage telego.EditMessageTextParams) {
+	chatID := query.Message.GetChat().ID
+
+	var fields models.RequestFields
+	var attachments []models.Attachment
+	ok := sessions.Update(chatID, requestFlow, func(s *session.Session) {
+		s.Transition(session.State(models.StepConfirm))
+		delete(s.Data, requestKeyEditing)
+		s.SetInt(requestKeyPrompt, query.Message.GetMessageID())
+		fields = requestFields(s)
+		attachments = sessionAttachments(s, requestKeyAttachments)
+	})
+	if !ok {
+		handleBackCallback(bot, query, sessions, editedMessage)
+		return
+	}
+
+	editedMessage.Text = formatRequestSummary(fields, attachments)
+	editedMessage.ReplyMarkup = markup.GetRequestSummaryMarkup()
+	bot.EditMessageText(&editedMessage)
+}
+
+func handleRequestEditCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, editedMessage telego.EditMessageTextParams) {
+	chatID := query.Message.GetChat().ID
+
+	if flow, _, ok := sessions.Active(chatID); !ok || flow != requestFlow {
+		handleBackCallback(bot, query, sessions, editedMessage)
+		return
+	}
+
+	editedMessage.Text = "What do you want to change?"
+	editedMessage.ReplyMarkup = markup.GetRequestEditMarkup()
+	bot.EditMessageText(&editedMessage)
+}
+
+func handleRequestEditFieldCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, categories []models.Category, editedMessage telego.EditMessageTextParams) {
+	chatID := query.Message.GetChat().ID
+	step := requestEditSteps[query.Data]
+
+	ok := sessions.Update(chatID, requestFlow, func(s *session.Session) {
+		s.Transition(session.State(step))
+		s.Data[requestKeyEditing] = "1"
+		s.SetInt(requestKeyPrompt, query.Message.GetMessageID())
+	})
+	if !ok {
+		handleBackCallback(bot, query, sessions, editedMessage)
+		return
+	}
+
+	editedMessage.Text = utils.GetRequestStepPrompt(step)
+	editedMessage.ReplyMarkup = markup.GetRequestStepMarkup(step, categories)
+	bot.EditMessageText(&editedMessage)
+}
+
+// requestCategoryCallbackHandler answers the direction question with the
+// category picked from the buttons under it.
+func requestCategoryCallbackHandler(_ *telego.Bot, sessions *session.Manager, categories []models.Category, errorLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+	return func(bot *telego.Bot, query telego.CallbackQuery) {
+		_ = bot.AnswerCallbackQuery(tu.CallbackQuery(query.ID))
+
+		key := strings.TrimPrefix(query.Data, "request_category:")
+		var category models.Category
+		for _, candidate := range categories {
+			if candidate.Key == key {
+				category = candidate
+				break
+			}
+		}
+		if category.Key == "" {
+			return
+		}
+
+		chatID := query.Message.GetChat().ID
+		sessions.Update(chatID, requestFlow, func(s *session.Session) {
+			if models.RequestStep(s.State) != models.StepDirection {
+				return
+			}
+			s.Data[string(models.StepDirection)] = category.Title
+			// The answer didn't come from a message, so there is nothing to edit later.
+			delete(s.Data, requestMessageKey(models.StepDirection))
+			s.SetInt(requestKeyPrompt, query.Message.GetMessageID())
+
+			next := nextRequestStep(s)
+			if s.Data[requestKeyEditing] != "" {
+				next = models.StepConfirm
+				delete(s.Data, requestKeyEditing)
+			}
+			s.Transition(session.State(next))
+
+			editedMessage := telego.EditMessageTextParams{
+				ChatID:      tu.ID(chatID),
+				MessageID:   query.Message.GetMessageID(),
+				ParseMode:   telego.ModeHTML,
+				Text:        utils.GetRequestStepPrompt(next),
+				ReplyMarkup: markup.GetRequestStepMarkup(next, categories),
+			}
+			if next == models.StepConfirm {
+				editedMessage.Text = formatRequestSummary(requestFields(s), sessionAttachments(s, requestKeyAttachments))
+				editedMessage.ReplyMarkup = markup.GetRequestSummaryMarkup()
+			}
+			if _, err := bot.EditMessageText(&editedMessage); err != nil {
+				errorLogger.Println("Failed to edit request prompt:", err)
+			}
+		})
+	}
+}
+
+func handleRequestCancelCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, editedMessage telego.EditMessageTextParams) {
+	handleBackCallback(bot, query, sessions, editedMessage)
+}
+
+func handleRequestConfirmCallback(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, cleaner *messageCleaner, reminders *ticketReminders, sessions *session.Manager, limiter *limits.Limiter, authorizer *auth.Authorizer, categories []models.Category, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	chatID := query.Message.GetChat().ID
+
+	var fields models.RequestFields
+	var attachments []models.Attachment
+	var fieldMessages map[models.RequestStep]int
+	confirmed := false
+	sessions.Update(chatID, requestFlow, func(s *session.Session) {
+		if models.RequestStep(s.State) != models.StepConfirm {
+			return
+		}
+		fields = requestFields(s)
+		attachments = sessionAttachments(s, requestKeyAttachments)
+		fieldMessages = requestFieldMessages(s)
+		confirmed = true
+		s.End()
+	})
+	if !confirmed {
+		return
+	}
+
+	now := time.Now()
+	denial := checkRequestLimits(query.From.ID, limiter, authorizer, errorLogger)
+	if denial == nil && !authorizer.Allows(query.From.ID, models.RoleViewer) {
+		var err error
+		if denial, err = limiter.CheckDuplicate(query.From.ID, fields, now); err != nil {
+			errorLogger.Println("Failed to check duplicate requests:", err)
+		}
+	}
+	if denial != nil {
+		editedMessage.Text = utils.FormatLimitDenial(denial, now)
+		editedMessage.ReplyMarkup = markup.GetBackMarkup()
+		bot.EditMessageText(&editedMessage)
+		return
+	}
+
+	ticket := &models.Ticket{
+		RequesterID:   query.From.ID,
+		ChatID:        chatID,
+		Username:      query.From.Username,
+		Text:          utils.RequestFieldsText(fields),
+		Fields:        fields,
+		Attachments:   attachments,
+		FieldMessages: fieldMessages,
+		Status:        models.TicketNew,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	recipients := authorizer.MemberIDs(models.RoleReviewer)
+	if category, ok := utils.MatchCategory(categories, fields.Direction); ok {
+		ticket.Category = category.Key
+		if category.ChatID != 0 {
+			recipients = []int64{category.ChatID}
+		}
+	}
+	if err := tickets.Create(ticket); err != nil {
+		errorLogger.Println("Failed to store request ticket:", err)
+		editedMessage.Text = "Failed to save your request, please try again later."
+		bot.EditMessageText(&editedMessage)
+		return
+	}
+
+	for _, adminID := range recipients {
+		root, attachments, err := sendAdminCopy(bot, ticket, adminID, errorLogger)
+		if err != nil {
+			errorLogger.Println("Failed to send request message to admin:", err)
+			continue
+		}
+		// Store each copy right away: a reviewer may already act on the first one
+		// while the others are still being sent.
+		if _, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+			stored.AdminMessages = append(stored.AdminMessages, root)
+			stored.RelayMessages = append(stored.RelayMessages, attachments...)
+			return nil
+		}); err != nil {
+			errorLogger.Println("Failed to store admin messages of ticket:", err)
+		}
+	}
+	reminders.Schedule(ticket.ID)
+
+	editedMessage.Text = fmt.Sprintf("Thank you for your job request <b>#%d</b>.\n\nI'll write you after reviewing your request", ticket.ID) + cleaner.Notice(models.MessageConfirmation)
+	editedMessage.ReplyMarkup = nil
+	if _, err := bot.EditMessageText(&editedMessage); err != nil {
+		errorLogger.Println("Failed to send confirmation message:", err)
+		return
+	}
+
+	cleaner.DeleteLater(models.MessageConfirmation, chatID, query.Message.GetMessageID())
+}
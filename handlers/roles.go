@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+)
+
+// assignCommandHandler lets owners hand a ticket over to a reviewer: /assign <ticket> <user ID>.
+func assignCommandHandler(_ *telego.Bot, tickets storage.TicketStore, authorizer *auth.Authorizer, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 3 {
+			sendText(bot, chatID, "Usage: /assign &lt;ticket&gt; &lt;user ID&gt;", errorLogger)
+			return
+		}
+
+		userID, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("Invalid user ID: <code>%s</code>", html.EscapeString(parts[2])), errorLogger)
+			return
+		}
+		if !authorizer.Allows(userID, models.RoleReviewer) {
+			sendText(bot, chatID, fmt.Sprintf("User <code>%d</code> is not a reviewer.", userID), errorLogger)
+			return
+		}
+
+		ticket, ok := loadTicket(bot, chatID, tickets, parts[1], errorLogger)
+		if !ok {
+			return
+		}
+		if ticket.Status != models.TicketNew && ticket.Status != models.TicketAccepted {
+			sendText(bot, chatID, fmt.Sprintf("Ticket <b>#%d</b> is already <b>%s</b>.", ticket.ID, ticket.Status), errorLogger)
+			return
+		}
+
+		assignee, err := bot.GetChat(&telego.GetChatParams{ChatID: tu.ID(userID)})
+		if err != nil {
+			errorLogger.Printf("Failed to get chat of user %d: %v", userID, err)
+			assignee = &telego.ChatFullInfo{ID: userID, FirstName: strconv.FormatInt(userID, 10)}
+		}
+
+		assigned, err := assignTicket(bot, tickets, ticket, *assignee, errorLogger)
+		if err != nil {
+			errorLogger.Println("Failed to assign ticket:", err)
+			return
+		}
+
+		auditLogger.Printf("User %d assigned ticket %d to user %d", update.Message.From.ID, assigned.ID, userID)
+		sendText(bot, chatID, fmt.Sprintf("Ticket <b>#%d</b> is assigned to %s.", assigned.ID, html.EscapeString(assigned.AssigneeName)), errorLogger)
+	}
+}
+
+// roleCommandHandler grants or revokes team roles: /role <user ID> <owner|reviewer|viewer|none>.
+func roleCommandHandler(_ *telego.Bot, authorizer *auth.Authorizer, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		parts := strings.Fields(update.Message.Text)
+		if len(parts) < 3 {
+			sendText(bot, chatID, "Usage: /role &lt;user ID&gt; &lt;owner|reviewer|viewer|none&gt;", errorLogger)
+			return
+		}
+
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			sendText(bot, chatID, fmt.Sprintf("Invalid user ID: <code>%s</code>", html.EscapeString(parts[1])), errorLogger)
+			return
+		}
+
+		if parts[2] == "none" {
+			err = authorizer.RemoveRole(userID)
+		} else {
+			role, ok := models.ParseRole(parts[2])
+			if !ok {
+				sendText(bot, chatID, fmt.Sprintf("Unknown role: <code>%s</code>", html.EscapeString(parts[2])), errorLogger)
+				return
+			}
+			err = authorizer.SetRole(userID, role)
+		}
+
+		if errors.Is(err, auth.ErrConfiguredOwner) {
+			sendText(bot, chatID, fmt.Sprintf("User <code>%d</code> is an owner from the configuration, change ADMIN_IDS instead.", userID), errorLogger)
+			return
+		}
+		if err != nil {
+			errorLogger.Println("Failed to update role:", err)
+			sendText(bot, chatID, "Failed to update the role.", errorLogger)
+			return
+		}
+
+		auditLogger.Printf("User %d set role of user %d to %s", update.Message.From.ID, userID, parts[2])
+		sendText(bot, chatID, fmt.Sprintf("Role of <code>%d</code> is now <b>%s</b>.", userID, html.EscapeString(parts[2])), errorLogger)
+	}
+}
+
+func rolesCommandHandler(_ *telego.Bot, authorizer *auth.Authorizer, errorLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		message := "<b>Team</b>\n"
+		for _, member := range authorizer.Members() {
+			message += fmt.Sprintf("\n<code>%d</code> | <b>%s</b>", member.UserID, member.Role)
+			if member.Configured {
+				message += " (config)"
+			}
+		}
+
+		sendText(bot, update.Message.Chat.ID, message, errorLogger)
+	}
+}
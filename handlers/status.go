@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const statusTicketLimit = 10
+
+var errTicketNotPending = errors.New("ticket is not pending anymore")
+
+func statusCommandHandler(_ *telego.Bot, tickets storage.TicketStore, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		workLogger.Printf("Received /status command from user %d", update.Message.From.ID)
+		chatID := update.Message.Chat.ID
+
+		_ = bot.DeleteMessage(tu.Delete(
+			tu.ID(chatID),
+			update.Message.MessageID,
+		))
+
+		text, statusMarkup := renderRequesterStatus(tickets, update.Message.From.ID, errorLogger)
+		message := tu.Message(tu.ID(chatID), text)
+		message.ParseMode = telego.ModeHTML
+		message = message.WithReplyMarkup(statusMarkup)
+		if _, err := bot.SendMessage(message); err != nil {
+			errorLogger.Println("Failed to send status message:", err)
+		}
+	}
+}
+
+func handleMyRequestsCallback(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, editedMessage telego.EditMessageTextParams, errorLogger *log.Logger) {
+	editedMessage.Text, editedMessage.ReplyMarkup = renderRequesterStatus(tickets, query.From.ID, errorLogger)
+	bot.EditMessageText(&editedMessage)
+}
+
+func renderRequesterStatus(tickets storage.TicketStore, userID int64, errorLogger *log.Logger) (string, *telego.InlineKeyboardMarkup) {
+	all, err := tickets.List()
+	if err != nil {
+		errorLogger.Println("Failed to list tickets:", err)
+		return "Failed to load your requests, please try again later.", markup.GetBackMarkup()
+	}
+
+	own := make([]*models.Ticket, 0)
+	for _, ticket := range utils.FilterTickets(all, "", "") {
+		if ticket.RequesterID == userID {
+			own = append(own, ticket)
+		}
+	}
+
+	text := utils.FormatRequesterTickets(own, statusTicketLimit)
+	if len(own) > statusTicketLimit {
+		own = own[:statusTicketLimit]
+	}
+	return text, markup.GetRequesterTicketsMarkup(own)
+}
+
+// requesterCallbackHandler handles the buttons requesters use on their own tickets.
+func requesterCallbackHandler(_ *telego.Bot, tickets storage.TicketStore, errorLogger, workLogger *log.Logger) func(*telego.Bot, telego.CallbackQuery) {
+	return func(bot *telego.Bot, query telego.CallbackQuery) {
+		workLogger.Printf("Received requester callback query from user %d: %s", query.From.ID, query.Data)
+
+		editedMessage := telego.EditMessageTextParams{
+			ChatID:    tu.ID(query.Message.GetChat().ID),
+			MessageID: query.Message.GetMessageID(),
+			ParseMode: telego.ModeHTML,
+		}
+
+		action, id, ok := parseTicketCallback(strings.TrimPrefix(query.Data, "my_"))
+		if !ok {
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+			return
+		}
+
+		ticket, err := tickets.Get(id)
+		if err != nil || ticket.RequesterID != query.From.ID {
+			answerCallback(bot, query, fmt.Sprintf("Request #%d not found.", id))
+			return
+		}
+		if ticket.Status != models.TicketNew {
+			answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+			handleMyRequestsCallback(bot, query, tickets, editedMessage, errorLogger)
+			return
+		}
+
+		switch action {
+		case "withdraw":
+			editedMessage.Text = fmt.Sprintf("Do you want to withdraw your request <b>#%d</b>?", ticket.ID)
+			editedMessage.ReplyMarkup = markup.GetWithdrawConfirmMarkup(ticket.ID)
+			bot.EditMessageText(&editedMessage)
+		case "confirm":
+			if err := withdrawTicket(bot, tickets, ticket.ID); err != nil {
+				if errors.Is(err, errTicketNotPending) {
+					answerCallback(bot, query, fmt.Sprintf("Request #%d can't be withdrawn anymore.", ticket.ID))
+				} else {
+					errorLogger.Println("Failed to withdraw ticket:", err)
+				}
+			} else {
+				answerCallback(bot, query, fmt.Sprintf("Request #%d withdrawn.", ticket.ID))
+			}
+			handleMyRequestsCallback(bot, query, tickets, editedMessage, errorLogger)
+		default:
+			workLogger.Printf("Unknown callback data: %s", query.Data)
+		}
+	}
+}
+
+func withdrawTicket(bot *telego.Bot, tickets storage.TicketStore, id int64) error {
+	withdrawn, err := tickets.Modify(id, func(stored *models.Ticket) error {
+		if !stored.CanTransition(models.TicketWithdrawn) {
+			return errTicketNotPending
+		}
+		stored.Status = models.TicketWithdrawn
+		stored.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	refreshAdminMessages(bot, withdrawn)
+	return nil
+}
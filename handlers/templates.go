@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mymmrac/telego"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+const templateUsage = `Usage:
+/template add &lt;accept|decline&gt; &lt;name&gt; &lt;text&gt;
+/template list
+/template remove &lt;name&gt;`
+
+// templateNamePattern keeps names short enough to fit in callback data.
+var templateNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// templateCommandHandler manages the reply templates: /template add|list|remove.
+func templateCommandHandler(_ *telego.Bot, templates storage.TemplateStore, errorLogger, auditLogger *log.Logger) func(*telego.Bot, telego.Update) {
+	return func(bot *telego.Bot, update telego.Update) {
+		chatID := update.Message.Chat.ID
+		args, text := commandArgs(update.Message.Text, 4)
+		if len(args) < 2 {
+			sendText(bot, chatID, templateUsage, errorLogger)
+			return
+		}
+
+		switch args[1] {
+		case "list":
+			sendText(bot, chatID, utils.FormatTemplateList(templates.List("")), errorLogger)
+		case "add":
+			if len(args) < 4 || text == "" {
+				sendText(bot, chatID, templateUsage, errorLogger)
+				return
+			}
+			kind, ok := models.ParseTemplateKind(args[2])
+			if !ok {
+				sendText(bot, chatID, fmt.Sprintf("Unknown template kind: <code>%s</code>", html.EscapeString(args[2])), errorLogger)
+				return
+			}
+			name := strings.ToLower(args[3])
+			if !templateNamePattern.MatchString(name) {
+				sendText(bot, chatID, "Template names may only contain up to 32 letters, digits, dashes and underscores.", errorLogger)
+				return
+			}
+
+			template := &models.Template{
+				Name:      name,
+				Kind:      kind,
+				Text:      text,
+				CreatedBy: update.Message.From.ID,
+				CreatedAt: time.Now(),
+			}
+			if err := templates.Save(template); err != nil {
+				errorLogger.Println("Failed to store template:", err)
+				sendText(bot, chatID, "Failed to save the template.", errorLogger)
+				return
+			}
+
+			auditLogger.Printf("User %d saved %s template %q", template.CreatedBy, kind, name)
+			sendText(bot, chatID, fmt.Sprintf("Template <code>%s</code> saved.", html.EscapeString(name)), errorLogger)
+		case "remove":
+			if len(args) < 3 {
+				sendText(bot, chatID, templateUsage, errorLogger)
+				return
+			}
+			name := strings.ToLower(args[2])
+			removed, err := templates.Remove(name)
+			if err != nil {
+				errorLogger.Println("Failed to remove template:", err)
+				sendText(bot, chatID, "Failed to remove the template.", errorLogger)
+				return
+			}
+			if !removed {
+				sendText(bot, chatID, fmt.Sprintf("Template <code>%s</code> not found.", html.EscapeString(name)), errorLogger)
+				return
+			}
+
+			auditLogger.Printf("User %d removed template %q", update.Message.From.ID, name)
+			sendText(bot, chatID, fmt.Sprintf("Template <code>%s</code> removed.", html.EscapeString(name)), errorLogger)
+		default:
+			sendText(bot, chatID, templateUsage, errorLogger)
+		}
+	}
+}
+
+// commandArgs splits off the first n words of the command text and returns the
+// rest as is, so multi-line template texts keep their line breaks.
+func commandArgs(text string, n int) ([]string, string) {
+	args := make([]string, 0, n)
+	rest := strings.TrimSpace(text)
+	for len(args) < n && rest != "" {
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		}
+		args = append(args, rest[:end])
+		rest = strings.TrimSpace(rest[end:])
+	}
+	return args, rest
+}
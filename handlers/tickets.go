@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/auth"
+	"github.com/pureheroky/tg-golang-bot/markup"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/session"
+	"github.com/pureheroky/tg-golang-bot/storage"
+	"github.com/pureheroky/tg-golang-bot/utils"
+)
+
+var errTicketClaimed = errors.New("ticket is claimed by another admin")
+
+const (
+	acceptFlow  = "accept"
+	declineFlow = "decline"
+	askFlow     = "ask"
+	answerFlow  = "answer"
+)
+
+const (
+	acceptStateMessage = session.State("message")
+	declineStateReason = session.State("reason")
+	askStateQuestion   = session.State("question")
+	answerStateText    = session.State("text")
+
+	ticketKeyID      = "ticket_id"
+	ticketKeyMessage = "message_id"
+	ticketKeyPrompt  = "prompt_message_id"
+)
+
+// ticketStatusError is returned when the stored ticket can't move to the
+// wanted status anymore, e.g. because another admin decided on it first.
+type ticketStatusError struct {
+	ID     int64
+	Status models.TicketStatus
+}
+
+func (e *ticketStatusError) Error() string {
+	return fmt.Sprintf("ticket %d is already %s", e.ID, e.Status)
+}
+
+// transitionTicket moves the stored ticket to the status, checking the
+// transition against the stored state rather than the caller's copy. fn sees
+// the ticket before its status changes.
+func transitionTicket(tickets storage.TicketStore, id int64, to models.TicketStatus, fn func(stored *models.Ticket)) (*models.Ticket, error) {
+	return tickets.Modify(id, func(stored *models.Ticket) error {
+		if !stored.CanTransition(to) {
+			return &ticketStatusError{ID: stored.ID, Status: stored.Status}
+		}
+		fn(stored)
+		stored.Status = to
+		stored.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// acceptTicket accepts the ticket, claiming it for the admin if nobody did yet.
+// A non-empty message is passed on to the requester.
+func acceptTicket(bot *telego.Bot, tickets storage.TicketStore, cleaner *messageCleaner, id int64, by telego.User, note string, errorLogger *log.Logger) (*models.Ticket, error) {
+	ticket, err := transitionTicket(tickets, id, models.TicketAccepted, func(stored *models.Ticket) {
+		if stored.AssigneeID == 0 {
+			stored.AssigneeID = by.ID
+			stored.AssigneeName = userDisplayName(by)
+		}
+		stored.Decision = note
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshAdminMessages(bot, ticket)
+
+	text := fmt.Sprintf("Your request <b>#%d</b> was accepted!", ticket.ID)
+	if note != "" {
+		text += fmt.Sprintf("\n\nDeveloper message: \n%s", html.EscapeString(note))
+	}
+	message := tu.Message(
+		tu.ID(ticket.ChatID),
+		text+"\n\nYou can now talk to the developer right here: just send your messages to this bot, the answers will come here too."+cleaner.Notice(models.MessageAccepted),
+	)
+	message.ParseMode = telego.ModeHTML
+
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		errorLogger.Println("Failed to send acceptance message:", err)
+		return ticket, nil
+	}
+
+	cleaner.DeleteLater(models.MessageAccepted, ticket.ChatID, sentMessage.MessageID)
+	return ticket, nil
+}
+
+func declineTicket(bot *telego.Bot, tickets storage.TicketStore, cleaner *messageCleaner, feedback *ticketFeedback, id int64, reason string, errorLogger *log.Logger) (*models.Ticket, error) {
+	ticket, err := transitionTicket(tickets, id, models.TicketDeclined, func(stored *models.Ticket) {
+		declinedAt := time.Now()
+		stored.Decision = reason
+		stored.DeclinedAt = &declinedAt
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshAdminMessages(bot, ticket)
+	feedback.Schedule(ticket.ID)
+
+	message := tu.Message(
+		tu.ID(ticket.ChatID),
+		fmt.Sprintf("Your request <b>#%d</b> was declined!\n\nDeveloper message: \n%s", ticket.ID, html.EscapeString(reason))+cleaner.Notice(models.MessageDeclined),
+	)
+	message.ParseMode = telego.ModeHTML
+
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		errorLogger.Println("Failed to send decline message:", err)
+		return ticket, nil
+	}
+
+	cleaner.DeleteLater(models.MessageDeclined, ticket.ChatID, sentMessage.MessageID)
+	return ticket, nil
+}
+
+func closeTicket(bot *telego.Bot, tickets storage.TicketStore, feedback *ticketFeedback, id int64, errorLogger *log.Logger) (*models.Ticket, error) {
+	wasAccepted := false
+	ticket, err := transitionTicket(tickets, id, models.TicketClosed, func(stored *models.Ticket) {
+		wasAccepted = stored.Status == models.TicketAccepted
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshAdminMessages(bot, ticket)
+
+	// Declined tickets were already asked for feedback when they were declined.
+	if wasAccepted {
+		feedback.Schedule(ticket.ID)
+		sendText(bot, ticket.ChatID, fmt.Sprintf("Your request <b>#%d</b> was closed. The conversation with the developer is finished.", ticket.ID), errorLogger)
+	}
+	return ticket, nil
+}
+
+// ticketDecisionFailed returns what to tell the admin when a decision could not
+// be saved, logging unexpected errors.
+func ticketDecisionFailed(err error, errorLogger *log.Logger) string {
+	var statusErr *ticketStatusError
+	if errors.As(err, &statusErr) {
+		return fmt.Sprintf("Request #%d is already %s.", statusErr.ID, statusErr.Status)
+	}
+	errorLogger.Println("Failed to update ticket:", err)
+	return "Failed to update the request."
+}
+
+// refreshAdminMessages re-renders every admin copy of the ticket to show its current state.
+func refreshAdminMessages(bot *telego.Bot, ticket *models.Ticket) {
+	for _, ref := range ticket.AdminMessages {
+		bot.EditMessageText(&telego.EditMessageTextParams{
+			ChatID:      tu.ID(ref.ChatID),
+			MessageID:   ref.MessageID,
+			ParseMode:   telego.ModeHTML,
+			Text:        utils.FormatAdminRequest(ticket),
+			ReplyMarkup: markup.GetRequestActionsMarkup(ticket),
+		})
+	}
+}
+
+// renderTicketView updates the message a decision was made from: admin copies are
+// already refreshed by the decision itself, inbox views show the ticket details.
+func renderTicketView(bot *telego.Bot, ticket *models.Ticket, chatID int64, messageID int) {
+	if messageID == 0 || ticket.HasAdminMessage(chatID, messageID) {
+		return
+	}
+
+	bot.EditMessageText(&telego.EditMessageTextParams{
+		ChatID:      tu.ID(chatID),
+		MessageID:   messageID,
+		ParseMode:   telego.ModeHTML,
+		Text:        utils.FormatTicketDetails(ticket),
+		ReplyMarkup: markup.GetTicketMarkup(ticket),
+	})
+}
+
+// parseTicketCallback splits callback data of the form "ticket_<action>:<id>",
+// which may be followed by ":<argument>".
+func parseTicketCallback(data string) (string, int64, bool) {
+	action, rest, found := strings.Cut(strings.TrimPrefix(data, "ticket_"), ":")
+	if !found {
+		return "", 0, false
+	}
+	rawID, _, _ := strings.Cut(rest, ":")
+	id, err := strconv.ParseInt(rawID, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return action, id, true
+}
+
+// ticketCallbackArgument returns the argument following the ticket ID, if any.
+func ticketCallbackArgument(data string) string {
+	_, rest, _ := strings.Cut(data, ":")
+	_, argument, _ := strings.Cut(rest, ":")
+	return argument
+}
+
+func answerCallback(bot *telego.Bot, query telego.CallbackQuery, text string) {
+	_ = bot.AnswerCallbackQuery(tu.CallbackQuery(query.ID).WithText(text))
+}
+
+func registerTicketFlows(sessions *session.Manager, tickets storage.TicketStore, cleaner *messageCleaner, feedback *ticketFeedback, errorLogger *log.Logger) {
+	deletePrompt := func(bot *telego.Bot, s *session.Session) {
+		if promptID := s.Int(ticketKeyPrompt); promptID != 0 {
+			_ = bot.DeleteMessage(tu.Delete(tu.ID(s.ChatID), promptID))
+		}
+	}
+
+	sessions.Register(&session.Flow{
+		Name: acceptFlow,
+		Handlers: map[session.State]session.Handler{
+			acceptStateMessage: func(bot *telego.Bot, update telego.Update, s *session.Session) {
+				handleAcceptMessage(bot, update, s, tickets, cleaner, errorLogger)
+			},
+		},
+		OnExpire: deletePrompt,
+	})
+
+	sessions.Register(&session.Flow{
+		Name: declineFlow,
+		Handlers: map[session.State]session.Handler{
+			declineStateReason: func(bot *telego.Bot, update telego.Update, s *session.Session) {
+				handleDeclineReasonMessage(bot, update, s, tickets, cleaner, feedback, errorLogger)
+			},
+		},
+		OnExpire: deletePrompt,
+	})
+
+	sessions.Register(&session.Flow{
+		Name: askFlow,
+		Handlers: map[session.State]session.Handler{
+			askStateQuestion: func(bot *telego.Bot, update telego.Update, s *session.Session) {
+				handleAskQuestionMessage(bot, update, s, tickets, sessions, errorLogger)
+			},
+		},
+		OnExpire: deletePrompt,
+	})
+
+	sessions.Register(&session.Flow{
+		Name: answerFlow,
+		Handlers: map[session.State]session.Handler{
+			answerStateText: func(bot *telego.Bot, update telego.Update, s *session.Session) {
+				handleAnswerMessage(bot, update, s, tickets, errorLogger)
+			},
+		},
+	})
+}
+
+// startTicketPrompt asks the admin for a follow-up message about the ticket.
+func startTicketPrompt(bot *telego.Bot, query telego.CallbackQuery, ticket *models.Ticket, sessions *session.Manager, flow string, state session.State, text string, replyMarkup *telego.InlineKeyboardMarkup, errorLogger *log.Logger) {
+	chatID := query.Message.GetChat().ID
+
+	message := tu.Message(tu.ID(chatID), text)
+	message.ParseMode = telego.ModeHTML
+	message = message.WithReplyMarkup(replyMarkup)
+
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		errorLogger.Println("Failed to send prompt:", err)
+		return
+	}
+
+	sessions.Start(chatID, flow, state, func(s *session.Session) {
+		s.SetInt64(ticketKeyID, ticket.ID)
+		s.SetInt(ticketKeyMessage, query.Message.GetMessageID())
+		s.SetInt(ticketKeyPrompt, sentMessage.MessageID)
+	})
+}
+
+func handleTicketAcceptCallback(bot *telego.Bot, query telego.CallbackQuery, ticket *models.Ticket, sessions *session.Manager, templates []*models.Template, errorLogger *log.Logger) {
+	startTicketPrompt(bot, query, ticket, sessions, acceptFlow, acceptStateMessage,
+		fmt.Sprintf("Send a message for the requester of request <b>#%d</b> or pick a template.", ticket.ID),
+		markup.GetAcceptPromptMarkup(ticket.ID, templates), errorLogger)
+}
+
+func handleTicketDeclineCallback(bot *telego.Bot, query telego.CallbackQuery, ticket *models.Ticket, sessions *session.Manager, templates []*models.Template, errorLogger *log.Logger) {
+	text := fmt.Sprintf("Send the decline reason for request <b>#%d</b>.", ticket.ID)
+	if len(templates) > 0 {
+		text = fmt.Sprintf("Send the decline reason for request <b>#%d</b> or pick a template.", ticket.ID)
+	}
+	startTicketPrompt(bot, query, ticket, sessions, declineFlow, declineStateReason, text,
+		markup.GetDeclinePromptMarkup(ticket.ID, templates), errorLogger)
+}
+
+func handleTicketAskCallback(bot *telego.Bot, query telego.CallbackQuery, ticket *models.Ticket, sessions *session.Manager, errorLogger *log.Logger) {
+	startTicketPrompt(bot, query, ticket, sessions, askFlow, askStateQuestion,
+		fmt.Sprintf("Send your question about request <b>#%d</b>.", ticket.ID),
+		markup.GetPromptCancelMarkup(), errorLogger)
+}
+
+// handleTicketTemplateCallback accepts or declines the ticket with the picked
+// template, filled in from the request.
+func handleTicketTemplateCallback(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, cleaner *messageCleaner, feedback *ticketFeedback, sessions *session.Manager, templates storage.TemplateStore, ticket *models.Ticket, errorLogger *log.Logger) {
+	template, ok := templates.Get(ticketCallbackArgument(query.Data))
+	if !ok {
+		answerCallback(bot, query, "Template not found.")
+		return
+	}
+
+	status := models.TicketAccepted
+	if template.Kind == models.TemplateDecline {
+		status = models.TicketDeclined
+	}
+	if !ticket.CanTransition(status) {
+		answerCallback(bot, query, fmt.Sprintf("Request #%d is already %s.", ticket.ID, ticket.Status))
+		return
+	}
+
+	messageID := finishTicketPrompt(bot, query, sessions, ticket.ID)
+	text := utils.FillTemplate(template.Text, ticket)
+
+	var decided *models.Ticket
+	var err error
+	if status == models.TicketDeclined {
+		decided, err = declineTicket(bot, tickets, cleaner, feedback, ticket.ID, text, errorLogger)
+	} else {
+		decided, err = acceptTicket(bot, tickets, cleaner, ticket.ID, query.From, text, errorLogger)
+	}
+	if err != nil {
+		answerCallback(bot, query, ticketDecisionFailed(err, errorLogger))
+		return
+	}
+
+	renderTicketView(bot, decided, query.Message.GetChat().ID, messageID)
+}
+
+// finishTicketPrompt ends the accept or decline prompt the button was pressed
+// on and returns the message the prompt was started from.
+func finishTicketPrompt(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager, ticketID int64) int {
+	chatID := query.Message.GetChat().ID
+	messageID := 0
+	for _, flow := range []string{acceptFlow, declineFlow} {
+		sessions.Update(chatID, flow, func(s *session.Session) {
+			if s.Int64(ticketKeyID) == ticketID {
+				messageID = s.Int(ticketKeyMessage)
+				s.End()
+			}
+		})
+	}
+
+	_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), query.Message.GetMessageID()))
+	return messageID
+}
+
+func handleTicketClaimCallback(bot *telego.Bot, query telego.CallbackQuery, tickets storage.TicketStore, authorizer *auth.Authorizer, id int64, errorLogger *log.Logger) {
+	if !authorizer.Allows(query.From.ID, models.RoleReviewer) {
+		answerCallback(bot, query, "You can't claim requests.")
+		return
+	}
+
+	// The reply is built from the stored ticket, another admin may have just claimed it.
+	var current models.Ticket
+	claimed, err := tickets.Modify(id, func(stored *models.Ticket) error {
+		current = *stored
+		if stored.Status != models.TicketNew && stored.Status != models.TicketAccepted {
+			return &ticketStatusError{ID: stored.ID, Status: stored.Status}
+		}
+		if stored.AssigneeID != 0 && stored.AssigneeID != query.From.ID {
+			return errTicketClaimed
+		}
+		stored.AssigneeID = query.From.ID
+		stored.AssigneeName = userDisplayName(query.From)
+		stored.UpdatedAt = time.Now()
+		return nil
+	})
+	if errors.Is(err, errTicketClaimed) {
+		answerCallback(bot, query, fmt.Sprintf("Request #%d is already claimed by %s.", current.ID, current.AssigneeName))
+		return
+	}
+	if err != nil {
+		answerCallback(bot, query, ticketDecisionFailed(err, errorLogger))
+		return
+	}
+
+	refreshAdminMessages(bot, claimed)
+	renderTicketView(bot, claimed, query.Message.GetChat().ID, query.Message.GetMessageID())
+	answerCallback(bot, query, fmt.Sprintf("Request #%d is yours.", claimed.ID))
+}
+
+// assignTicket hands the ticket over to another team member, sending them a copy
+// of the request if they did not get one when it was created.
+func assignTicket(bot *telego.Bot, tickets storage.TicketStore, ticket *models.Ticket, assignee telego.ChatFullInfo, errorLogger *log.Logger) (*models.Ticket, error) {
+	name := strings.TrimSpace(assignee.FirstName + " " + assignee.LastName)
+	if assignee.Username != "" {
+		name = "@" + assignee.Username
+	}
+
+	assigned, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		stored.AssigneeID = assignee.ID
+		stored.AssigneeName = name
+		stored.UpdatedAt = time.Now()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshAdminMessages(bot, assigned)
+
+	for _, ref := range assigned.AdminMessages {
+		if ref.ChatID == assignee.ID {
+			return assigned, nil
+		}
+	}
+
+	root, attachments, err := sendAdminCopy(bot, assigned, assignee.ID, errorLogger)
+	if err != nil {
+		errorLogger.Println("Failed to send request to assignee:", err)
+		return assigned, nil
+	}
+
+	return tickets.Modify(assigned.ID, func(stored *models.Ticket) error {
+		stored.AdminMessages = append(stored.AdminMessages, root)
+		stored.RelayMessages = append(stored.RelayMessages, attachments...)
+		return nil
+	})
+}
+
+// sendAdminCopy sends the request to an admin followed by its attachments. The
+// attachments are part of the ticket conversation, so replies to them reach the requester.
+func sendAdminCopy(bot *telego.Bot, ticket *models.Ticket, adminID int64, errorLogger *log.Logger) (models.MessageRef, []models.MessageRef, error) {
+	message := tu.Message(tu.ID(adminID), utils.FormatAdminRequest(ticket))
+	message.ParseMode = telego.ModeHTML
+	message = message.WithReplyMarkup(markup.GetRequestActionsMarkup(ticket))
+
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		return models.MessageRef{}, nil, err
+	}
+
+	root := models.MessageRef{ChatID: adminID, MessageID: sentMessage.MessageID}
+	return root, sendTicketAttachments(bot, ticket, adminID, sentMessage.MessageID, errorLogger), nil
+}
+
+func handleAcceptMessage(bot *telego.Bot, update telego.Update, s *session.Session, tickets storage.TicketStore, cleaner *messageCleaner, errorLogger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	note := strings.TrimSpace(update.Message.Text)
+	if note == "" {
+		sendText(bot, chatID, "Please send the message as text.", errorLogger)
+		return
+	}
+
+	s.End()
+	if promptID := s.Int(ticketKeyPrompt); promptID != 0 {
+		_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), promptID))
+	}
+
+	ticket, err := tickets.Get(s.Int64(ticketKeyID))
+	if err != nil {
+		errorLogger.Println("Failed to load ticket:", err)
+		sendText(bot, chatID, "Request not found.", errorLogger)
+		return
+	}
+	if !ticket.CanTransition(models.TicketAccepted) {
+		sendText(bot, chatID, fmt.Sprintf("Request <b>#%d</b> is already <b>%s</b>.", ticket.ID, ticket.Status), errorLogger)
+		return
+	}
+
+	accepted, err := acceptTicket(bot, tickets, cleaner, ticket.ID, *update.Message.From, note, errorLogger)
+	if err != nil {
+		sendText(bot, chatID, html.EscapeString(ticketDecisionFailed(err, errorLogger)), errorLogger)
+		return
+	}
+
+	renderTicketView(bot, accepted, chatID, s.Int(ticketKeyMessage))
+}
+
+func handleDeclineReasonMessage(bot *telego.Bot, update telego.Update, s *session.Session, tickets storage.TicketStore, cleaner *messageCleaner, feedback *ticketFeedback, errorLogger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	reason := strings.TrimSpace(update.Message.Text)
+	if reason == "" {
+		sendText(bot, chatID, "Please send the reason as a text message.", errorLogger)
+		return
+	}
+
+	s.End()
+	if promptID := s.Int(ticketKeyPrompt); promptID != 0 {
+		_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), promptID))
+	}
+
+	ticket, err := tickets.Get(s.Int64(ticketKeyID))
+	if err != nil {
+		errorLogger.Println("Failed to load ticket:", err)
+		sendText(bot, chatID, "Request not found.", errorLogger)
+		return
+	}
+	if !ticket.CanTransition(models.TicketDeclined) {
+		sendText(bot, chatID, fmt.Sprintf("Request <b>#%d</b> is already <b>%s</b>.", ticket.ID, ticket.Status), errorLogger)
+		return
+	}
+
+	declined, err := declineTicket(bot, tickets, cleaner, feedback, ticket.ID, reason, errorLogger)
+	if err != nil {
+		sendText(bot, chatID, html.EscapeString(ticketDecisionFailed(err, errorLogger)), errorLogger)
+		return
+	}
+
+	renderTicketView(bot, declined, chatID, s.Int(ticketKeyMessage))
+}
+
+func handleAskQuestionMessage(bot *telego.Bot, update telego.Update, s *session.Session, tickets storage.TicketStore, sessions *session.Manager, errorLogger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	question := strings.TrimSpace(update.Message.Text)
+	if question == "" {
+		sendText(bot, chatID, "Please send the question as a text message.", errorLogger)
+		return
+	}
+
+	s.End()
+	if promptID := s.Int(ticketKeyPrompt); promptID != 0 {
+		_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), promptID))
+	}
+
+	ticket, err := tickets.Get(s.Int64(ticketKeyID))
+	if err != nil {
+		errorLogger.Println("Failed to load ticket:", err)
+		sendText(bot, chatID, "Request not found.", errorLogger)
+		return
+	}
+
+	// Replying to the question always reaches the admins, as it is stored with the
+	// ticket. The next message is taken as the answer only if the requester is
+	// not in the middle of another conversation with the bot, like a new request.
+	nextMessage := sessions.StartIdle(ticket.ChatID, answerFlow, answerStateText, func(answer *session.Session) {
+		answer.SetInt64(ticketKeyID, ticket.ID)
+	})
+	howToAnswer := "Please reply to this message with your answer."
+	if nextMessage {
+		howToAnswer = "Please send your answer in the next message or reply to this one."
+	}
+
+	message := tu.Message(
+		tu.ID(ticket.ChatID),
+		fmt.Sprintf("Developer has a question about your request <b>#%d</b>:\n\n%s\n\n%s", ticket.ID, html.EscapeString(question), howToAnswer),
+	)
+	message.ParseMode = telego.ModeHTML
+	sentMessage, err := bot.SendMessage(message)
+	if err != nil {
+		errorLogger.Println("Failed to send question to requester:", err)
+		sessions.Update(ticket.ChatID, answerFlow, func(answer *session.Session) {
+			if answer.Int64(ticketKeyID) == ticket.ID {
+				answer.End()
+			}
+		})
+		sendText(bot, chatID, fmt.Sprintf("Failed to deliver the question for request <b>#%d</b>.", ticket.ID), errorLogger)
+		return
+	}
+
+	if _, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		stored.RelayMessages = append(stored.RelayMessages, models.MessageRef{ChatID: ticket.ChatID, MessageID: sentMessage.MessageID})
+		return nil
+	}); err != nil {
+		errorLogger.Println("Failed to store question message:", err)
+	}
+
+	sendText(bot, chatID, fmt.Sprintf("Question for request <b>#%d</b> sent.", ticket.ID), errorLogger)
+}
+
+func handleAnswerMessage(bot *telego.Bot, update telego.Update, s *session.Session, tickets storage.TicketStore, errorLogger *log.Logger) {
+	chatID := update.Message.Chat.ID
+	answer := strings.TrimSpace(update.Message.Text)
+	if answer == "" {
+		sendText(bot, chatID, "Please send your answer as a text message.", errorLogger)
+		return
+	}
+
+	s.End()
+
+	ticket, err := tickets.Get(s.Int64(ticketKeyID))
+	if err != nil {
+		errorLogger.Println("Failed to load ticket:", err)
+		return
+	}
+
+	refs := make([]models.MessageRef, 0, len(ticket.AdminMessages))
+	for _, ref := range ticket.AdminMessages {
+		message := tu.Message(
+			tu.ID(ref.ChatID),
+			fmt.Sprintf("Answer for request <b>#%d</b>:\n\n%s", ticket.ID, html.EscapeString(answer)),
+		)
+		message.ParseMode = telego.ModeHTML
+		message = message.WithReplyParameters(&telego.ReplyParameters{
+			MessageID:                ref.MessageID,
+			AllowSendingWithoutReply: true,
+		})
+		sentMessage, err := bot.SendMessage(message)
+		if err != nil {
+			errorLogger.Println("Failed to send answer to admin:", err)
+			continue
+		}
+		refs = append(refs, models.MessageRef{ChatID: ref.ChatID, MessageID: sentMessage.MessageID})
+	}
+
+	if _, err := tickets.Modify(ticket.ID, func(stored *models.Ticket) error {
+		stored.RelayMessages = append(stored.RelayMessages, refs...)
+		return nil
+	}); err != nil {
+		errorLogger.Println("Failed to store answer messages:", err)
+	}
+
+	sendText(bot, chatID, "Thank you, your answer was sent to the developer.", errorLogger)
+}
+
+func handlePromptCancelCallback(bot *telego.Bot, query telego.CallbackQuery, sessions *session.Manager) {
+	chatID := query.Message.GetChat().ID
+	if flow, _, ok := sessions.Active(chatID); ok && (flow == acceptFlow || flow == declineFlow || flow == askFlow) {
+		sessions.End(chatID)
+	}
+	_ = bot.DeleteMessage(tu.Delete(tu.ID(chatID), query.Message.GetMessageID()))
+}
@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how often and how long apart failed requests are retried.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try too, 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Client is the HTTP client shared by every outbound call. It bounds each
+// attempt with a timeout and retries transient failures, network errors, 429
+// and 5xx answers, with exponential backoff and jitter. A Retry-After header
+// replaces the backoff, unless it asks to wait longer than MaxDelay: then the
+// answer is returned as is.
+type Client struct {
+	http  *http.Client
+	retry RetryPolicy
+}
+
+func New(timeout time.Duration, retry RetryPolicy) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+
+	return &Client{
+		http:  &http.Client{Timeout: timeout, Transport: transport},
+		retry: retry,
+	}
+}
+
+// Do sends the request, retrying it while the failure is transient. The body of
+// a retried request is rewound with GetBody, so requests with a body need one.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := c.http.Do(req)
+		if attempt >= c.retry.MaxAttempts || !transient(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header, time.Now()); ok {
+				if c.retry.MaxDelay > 0 && after > c.retry.MaxDelay {
+					return resp, nil
+				}
+				wait = after
+			}
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		if req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// Get is a shortcut for Do with a GET request bound to ctx.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func transient(ctx context.Context, resp *http.Response, err error) bool {
+	if err != nil {
+		// The caller gave up, trying again is pointless.
+		return ctx.Err() == nil && !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter reads the Retry-After header, given either in seconds or as a date.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// backoff doubles the delay with every attempt up to MaxDelay and picks a
+// random point in its upper half, so clients failing together don't retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << (attempt - 1)
+	if delay <= 0 || (c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay) {
+		delay = c.retry.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
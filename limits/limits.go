@@ -0,0 +1,116 @@
+package limits
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+)
+
+type Reason string
+
+const (
+	ReasonRateLimit Reason = "rate_limit"
+	ReasonCooldown  Reason = "cooldown"
+	ReasonDuplicate Reason = "duplicate"
+)
+
+// Denial explains why a request was refused and when the user may try again.
+// RetryAt is zero for duplicates, which are refused regardless of time.
+type Denial struct {
+	Reason  Reason
+	RetryAt time.Time
+}
+
+type Config struct {
+	// RequestLimit is the number of requests a user may send per RequestWindow, 0 disables the limit.
+	RequestLimit    int
+	RequestWindow   time.Duration
+	DeclineCooldown time.Duration
+	DuplicateWindow time.Duration
+}
+
+// Limiter decides whether a user may submit a request. It keeps no state of its
+// own, everything is derived from the stored tickets so limits survive restarts.
+type Limiter struct {
+	cfg     Config
+	tickets storage.TicketStore
+}
+
+func NewLimiter(cfg Config, tickets storage.TicketStore) *Limiter {
+	return &Limiter{cfg: cfg, tickets: tickets}
+}
+
+// Check reports whether the user is limited or cooling down after a decline.
+func (l *Limiter) Check(userID int64, now time.Time) (*Denial, error) {
+	all, err := l.tickets.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var recent []*models.Ticket
+	var cooldownUntil time.Time
+	for _, ticket := range all {
+		if ticket.RequesterID != userID {
+			continue
+		}
+		if now.Sub(ticket.CreatedAt) < l.cfg.RequestWindow {
+			recent = append(recent, ticket)
+		}
+		if ticket.DeclinedAt != nil {
+			if until := ticket.DeclinedAt.Add(l.cfg.DeclineCooldown); until.After(cooldownUntil) {
+				cooldownUntil = until
+			}
+		}
+	}
+
+	if cooldownUntil.After(now) {
+		return &Denial{Reason: ReasonCooldown, RetryAt: cooldownUntil}, nil
+	}
+
+	if l.cfg.RequestLimit > 0 && len(recent) >= l.cfg.RequestLimit {
+		// Tickets are listed by ID, so the oldest one in the window frees up first.
+		oldest := recent[len(recent)-l.cfg.RequestLimit]
+		return &Denial{Reason: ReasonRateLimit, RetryAt: oldest.CreatedAt.Add(l.cfg.RequestWindow)}, nil
+	}
+
+	return nil, nil
+}
+
+// CheckDuplicate reports whether the user already sent the same description in a recent ticket.
+func (l *Limiter) CheckDuplicate(userID int64, fields models.RequestFields, now time.Time) (*Denial, error) {
+	text := normalizeText(fields.Description)
+	if text == "" {
+		return nil, nil
+	}
+
+	all, err := l.tickets.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ticket := range all {
+		if ticket.RequesterID != userID {
+			continue
+		}
+		// A withdrawn request may be sent again once it was corrected.
+		if ticket.Status == models.TicketWithdrawn || now.Sub(ticket.CreatedAt) >= l.cfg.DuplicateWindow {
+			continue
+		}
+		if normalizeText(ticket.Fields.Description) == text {
+			return &Denial{Reason: ReasonDuplicate}, nil
+		}
+	}
+	return nil, nil
+}
+
+// normalizeText ignores case, punctuation and spacing, so a resent description
+// with a typo fixed in the punctuation still counts as a duplicate.
+func normalizeText(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
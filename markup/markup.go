@@ -1,8 +1,12 @@
 package markup
 
 import (
+	"fmt"
+
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/utils"
 )
 
 func GetMainMenuMarkup() *telego.InlineKeyboardMarkup {
@@ -12,6 +16,7 @@ func GetMainMenuMarkup() *telego.InlineKeyboardMarkup {
 			tu.InlineKeyboardButton("git").WithCallbackData("git"),
 			tu.InlineKeyboardButton("skills").WithCallbackData("skills"),
 			tu.InlineKeyboardButton("projects").WithCallbackData("projects"),
+			tu.InlineKeyboardButton("my requests").WithCallbackData("my_requests"),
 		)...,
 	)
 }
@@ -47,3 +52,192 @@ func GetGitMarkup() *telego.InlineKeyboardMarkup {
 		),
 	)
 }
+
+// GetRequestStepMarkup returns the buttons under a wizard question, the
+// direction question offers the categories to pick from.
+func GetRequestStepMarkup(step models.RequestStep, categories []models.Category) *telego.InlineKeyboardMarkup {
+	rows := make([][]telego.InlineKeyboardButton, 0)
+	if step == models.StepDirection && len(categories) > 0 {
+		buttons := make([]telego.InlineKeyboardButton, 0, len(categories))
+		for _, category := range categories {
+			buttons = append(buttons, tu.InlineKeyboardButton(category.Title).WithCallbackData("request_category:"+category.Key))
+		}
+		rows = append(rows, tu.InlineKeyboardCols(2, buttons...)...)
+	}
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("cancel").WithCallbackData("request_cancel"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+func GetRequestSummaryMarkup() *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("confirm").WithCallbackData("request_confirm"),
+			tu.InlineKeyboardButton("edit").WithCallbackData("request_edit"),
+		),
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("cancel").WithCallbackData("request_cancel"),
+		),
+	)
+}
+
+func GetRequestEditMarkup() *telego.InlineKeyboardMarkup {
+	rows := tu.InlineKeyboardCols(2,
+		tu.InlineKeyboardButton("name").WithCallbackData("request_edit_name"),
+		tu.InlineKeyboardButton("direction").WithCallbackData("request_edit_direction"),
+		tu.InlineKeyboardButton("description").WithCallbackData("request_edit_description"),
+		tu.InlineKeyboardButton("contact").WithCallbackData("request_edit_contact"),
+	)
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("back").WithCallbackData("request_summary"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+func GetInboxMarkup(tickets []*models.Ticket) *telego.InlineKeyboardMarkup {
+	rows := make([][]telego.InlineKeyboardButton, 0, len(tickets)+1)
+	for _, ticket := range tickets {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(utils.GetTicketTitle(ticket)).WithCallbackData(fmt.Sprintf("ticket_view:%d", ticket.ID)),
+		))
+	}
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("previous").WithCallbackData("previous_requests"),
+		tu.InlineKeyboardButton("next").WithCallbackData("next_requests"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+func GetTicketMarkup(ticket *models.Ticket) *telego.InlineKeyboardMarkup {
+	rows := make([][]telego.InlineKeyboardButton, 0, 2)
+	switch ticket.Status {
+	case models.TicketNew:
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("accept").WithCallbackData(fmt.Sprintf("ticket_accept:%d", ticket.ID)),
+			tu.InlineKeyboardButton("decline").WithCallbackData(fmt.Sprintf("ticket_decline:%d", ticket.ID)),
+		))
+	case models.TicketAccepted:
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("close").WithCallbackData(fmt.Sprintf("ticket_close:%d", ticket.ID)),
+		))
+	}
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("back").WithCallbackData("requests_back"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+// GetRequestActionsMarkup returns the buttons shown under the admin copy of a request.
+func GetRequestActionsMarkup(ticket *models.Ticket) *telego.InlineKeyboardMarkup {
+	ask := tu.InlineKeyboardButton("ask question").WithCallbackData(fmt.Sprintf("ticket_ask:%d", ticket.ID))
+
+	var rows [][]telego.InlineKeyboardButton
+	switch ticket.Status {
+	case models.TicketNew:
+		rows = append(rows,
+			tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton("accept").WithCallbackData(fmt.Sprintf("ticket_accept:%d", ticket.ID)),
+				tu.InlineKeyboardButton("decline").WithCallbackData(fmt.Sprintf("ticket_decline:%d", ticket.ID)),
+			),
+			tu.InlineKeyboardRow(ask),
+		)
+	case models.TicketAccepted:
+		rows = append(rows,
+			tu.InlineKeyboardRow(ask),
+			tu.InlineKeyboardRow(
+				tu.InlineKeyboardButton("close").WithCallbackData(fmt.Sprintf("ticket_close:%d", ticket.ID)),
+			),
+		)
+	default:
+		return nil
+	}
+
+	if ticket.AssigneeID == 0 {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("claim").WithCallbackData(fmt.Sprintf("ticket_claim:%d", ticket.ID)),
+		))
+	}
+	return tu.InlineKeyboard(rows...)
+}
+
+// GetRequesterTicketsMarkup lets the requester withdraw the requests that are still pending.
+func GetRequesterTicketsMarkup(tickets []*models.Ticket) *telego.InlineKeyboardMarkup {
+	rows := make([][]telego.InlineKeyboardButton, 0, len(tickets)+1)
+	for _, ticket := range tickets {
+		if ticket.Status != models.TicketNew {
+			continue
+		}
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton(fmt.Sprintf("withdraw #%d", ticket.ID)).WithCallbackData(fmt.Sprintf("my_ticket_withdraw:%d", ticket.ID)),
+		))
+	}
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("back").WithCallbackData("back"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+func GetWithdrawConfirmMarkup(ticketID int64) *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("yes, withdraw").WithCallbackData(fmt.Sprintf("my_ticket_confirm:%d", ticketID)),
+			tu.InlineKeyboardButton("back").WithCallbackData("my_requests"),
+		),
+	)
+}
+
+func GetPromptCancelMarkup() *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("cancel").WithCallbackData("prompt_cancel"),
+		),
+	)
+}
+
+// GetFeedbackRatingMarkup asks the requester to rate the handling of the ticket from 1 to 5 stars.
+func GetFeedbackRatingMarkup(ticketID int64) *telego.InlineKeyboardMarkup {
+	buttons := make([]telego.InlineKeyboardButton, 0, models.MaxRating)
+	for rating := 1; rating <= models.MaxRating; rating++ {
+		buttons = append(buttons, tu.InlineKeyboardButton(fmt.Sprintf("%d ★", rating)).WithCallbackData(fmt.Sprintf("feedback_rate:%d:%d", ticketID, rating)))
+	}
+	return tu.InlineKeyboard(tu.InlineKeyboardRow(buttons...))
+}
+
+func GetFeedbackCommentMarkup(ticketID int64) *telego.InlineKeyboardMarkup {
+	return tu.InlineKeyboard(
+		tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("skip").WithCallbackData(fmt.Sprintf("feedback_skip:%d", ticketID)),
+		),
+	)
+}
+
+// GetDeclinePromptMarkup offers the decline templates while the admin is asked for a reason.
+func GetDeclinePromptMarkup(ticketID int64, templates []*models.Template) *telego.InlineKeyboardMarkup {
+	rows := templateRows(ticketID, templates)
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("cancel").WithCallbackData("prompt_cancel"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+// GetAcceptPromptMarkup offers the accept templates and accepting without a message.
+func GetAcceptPromptMarkup(ticketID int64, templates []*models.Template) *telego.InlineKeyboardMarkup {
+	rows := templateRows(ticketID, templates)
+	rows = append(rows, tu.InlineKeyboardRow(
+		tu.InlineKeyboardButton("accept without message").WithCallbackData(fmt.Sprintf("ticket_accept_plain:%d", ticketID)),
+		tu.InlineKeyboardButton("cancel").WithCallbackData("prompt_cancel"),
+	))
+	return tu.InlineKeyboard(rows...)
+}
+
+func templateRows(ticketID int64, templates []*models.Template) [][]telego.InlineKeyboardButton {
+	if len(templates) == 0 {
+		return nil
+	}
+	buttons := make([]telego.InlineKeyboardButton, 0, len(templates))
+	for _, template := range templates {
+		buttons = append(buttons, tu.InlineKeyboardButton(template.Name).WithCallbackData(fmt.Sprintf("ticket_template:%d:%s", ticketID, template.Name)))
+	}
+	return tu.InlineKeyboardCols(2, buttons...)
+}
@@ -1,16 +1,20 @@
 package models
 
-import "sync"
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/github"
+)
 
 type DataStore struct {
 	sync.RWMutex
-	ProjectsData       []map[string]interface{}
-	GitData            map[string][]map[string]string
-	RequestData        []string
 	UserProjectIndex   map[int]int
 	UserGitCommitIndex map[int]int
-	Projects           map[int][]string
-	Git                map[string][]map[string]string
+	Projects           []github.Repository
+	// Git holds the latest commits keyed by repository name.
+	Git map[string][]github.Commit
 }
 
 type SkillsResponse struct {
@@ -18,7 +22,278 @@ type SkillsResponse struct {
 	Status int    `json:"status"`
 }
 
-type AwaitingRequests struct {
+type RequestStep string
+
+const (
+	StepName        RequestStep = "name"
+	StepDirection   RequestStep = "direction"
+	StepDescription RequestStep = "description"
+	StepContact     RequestStep = "contact"
+	StepConfirm     RequestStep = "confirm"
+)
+
+type RequestFields struct {
+	Name        string `json:"name"`
+	Direction   string `json:"direction"`
+	Description string `json:"description"`
+	Contact     string `json:"contact"`
+}
+
+type AttachmentType string
+
+const (
+	AttachmentDocument AttachmentType = "document"
+	AttachmentPhoto    AttachmentType = "photo"
+	AttachmentVoice    AttachmentType = "voice"
+)
+
+// Attachment is a file sent along with a request, kept by its Telegram file ID.
+type Attachment struct {
+	Type    AttachmentType `json:"type"`
+	FileID  string         `json:"file_id"`
+	Caption string         `json:"caption,omitempty"`
+}
+
+// Category is a kind of job offered in the request wizard. Requests of a
+// category with a ChatID go to that chat instead of the reviewers.
+type Category struct {
+	Key    string `json:"key"`
+	Title  string `json:"title"`
+	ChatID int64  `json:"chat_id,omitempty"`
+}
+
+// MaxRating is the best rating a requester can give.
+const MaxRating = 5
+
+// Feedback is the requester's rating of how their request was handled. It is
+// created when the requester is asked, Rating stays 0 until they answer.
+type Feedback struct {
+	Rating  int        `json:"rating,omitempty"`
+	Comment string     `json:"comment,omitempty"`
+	AskedAt time.Time  `json:"asked_at"`
+	RatedAt *time.Time `json:"rated_at,omitempty"`
+}
+
+type TicketStatus string
+
+const (
+	TicketNew       TicketStatus = "new"
+	TicketAccepted  TicketStatus = "accepted"
+	TicketDeclined  TicketStatus = "declined"
+	TicketClosed    TicketStatus = "closed"
+	TicketWithdrawn TicketStatus = "withdrawn"
+)
+
+var TicketStatuses = []TicketStatus{TicketNew, TicketAccepted, TicketDeclined, TicketClosed, TicketWithdrawn}
+
+// ticketTransitions lists the statuses a ticket may move to from each status.
+var ticketTransitions = map[TicketStatus][]TicketStatus{
+	TicketNew:      {TicketAccepted, TicketDeclined, TicketClosed, TicketWithdrawn},
+	TicketAccepted: {TicketClosed},
+	TicketDeclined: {TicketClosed},
+}
+
+type MessageRef struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+type Ticket struct {
+	ID            int64               `json:"id"`
+	RequesterID   int64               `json:"requester_id"`
+	ChatID        int64               `json:"chat_id"`
+	Username      string              `json:"username"`
+	Text          string              `json:"text"`
+	Fields        RequestFields       `json:"fields"`
+	Category      string              `json:"category,omitempty"`
+	Attachments   []Attachment        `json:"attachments,omitempty"`
+	FieldMessages map[RequestStep]int `json:"field_messages,omitempty"`
+	Status        TicketStatus        `json:"status"`
+	Decision      string              `json:"decision,omitempty"`
+	// DeclinedAt is when the ticket was declined, it stays set after the ticket is closed.
+	DeclinedAt    *time.Time   `json:"declined_at,omitempty"`
+	AssigneeID    int64        `json:"assignee_id,omitempty"`
+	AssigneeName  string       `json:"assignee_name,omitempty"`
+	AdminMessages []MessageRef `json:"admin_messages,omitempty"`
+	RelayMessages []MessageRef `json:"relay_messages,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	EditedAt      *time.Time   `json:"edited_at,omitempty"`
+	Feedback      *Feedback    `json:"feedback,omitempty"`
+}
+
+func ParseTicketStatus(value string) (TicketStatus, bool) {
+	for _, status := range TicketStatuses {
+		if string(status) == value {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// Clone returns a deep copy of the ticket.
+func (t *Ticket) Clone() *Ticket {
+	copied := *t
+	copied.Attachments = append([]Attachment(nil), t.Attachments...)
+	if t.FieldMessages != nil {
+		copied.FieldMessages = make(map[RequestStep]int, len(t.FieldMessages))
+		for step, messageID := range t.FieldMessages {
+			copied.FieldMessages[step] = messageID
+		}
+	}
+	copied.AdminMessages = append([]MessageRef(nil), t.AdminMessages...)
+	copied.RelayMessages = append([]MessageRef(nil), t.RelayMessages...)
+	if t.Feedback != nil {
+		feedback := *t.Feedback
+		copied.Feedback = &feedback
+	}
+	return &copied
+}
+
+// HasAdminMessage reports whether the message is one of the ticket's copies sent to admins.
+func (t *Ticket) HasAdminMessage(chatID int64, messageID int) bool {
+	for _, ref := range t.AdminMessages {
+		if ref.ChatID == chatID && ref.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMessage reports whether the message belongs to the ticket's conversation,
+// either as an admin copy or as a relayed message on either side.
+func (t *Ticket) HasMessage(chatID int64, messageID int) bool {
+	if t.HasAdminMessage(chatID, messageID) {
+		return true
+	}
+	for _, ref := range t.RelayMessages {
+		if ref.ChatID == chatID && ref.MessageID == messageID {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldSteps returns the wizard steps answered by the requester's message,
+// several when the whole request was sent in one message. Answer messages are
+// kept in FieldMessages so that editing one in the chat updates the ticket.
+func (t *Ticket) FieldSteps(messageID int) []RequestStep {
+	var steps []RequestStep
+	for step, id := range t.FieldMessages {
+		if id == messageID {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+func (t *Ticket) CanTransition(to TicketStatus) bool {
+	for _, status := range ticketTransitions[t.Status] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// InboxFilter is the admin's current view of the request inbox.
+type InboxFilter struct {
+	Status TicketStatus
+	Query  string
+	Page   int
+}
+
+type AdminInbox struct {
 	sync.RWMutex
-	M map[int64]bool
-}
\ No newline at end of file
+	M map[int64]*InboxFilter
+}
+
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"
+	RoleReviewer Role = "reviewer"
+	RoleOwner    Role = "owner"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:   1,
+	RoleReviewer: 2,
+	RoleOwner:    3,
+}
+
+func ParseRole(value string) (Role, bool) {
+	role := Role(value)
+	_, ok := roleRanks[role]
+	return role, ok
+}
+
+// Allows reports whether the role grants at least the required role's permissions.
+func (r Role) Allows(required Role) bool {
+	return roleRanks[r] > 0 && roleRanks[r] >= roleRanks[required]
+}
+
+// Ban blocks a user from using the bot. A nil ExpiresAt means the ban is permanent.
+type Ban struct {
+	UserID    int64      `json:"user_id"`
+	Reason    string     `json:"reason,omitempty"`
+	BannedBy  int64      `json:"banned_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Expiry returns when the ban ends, false for permanent bans.
+func (b *Ban) Expiry() (time.Time, bool) {
+	if b.ExpiresAt == nil {
+		return time.Time{}, false
+	}
+	return *b.ExpiresAt, true
+}
+
+func (b *Ban) Active(now time.Time) bool {
+	expiresAt, ok := b.Expiry()
+	return !ok || now.Before(expiresAt)
+}
+
+type TemplateKind string
+
+const (
+	TemplateAccept  TemplateKind = "accept"
+	TemplateDecline TemplateKind = "decline"
+)
+
+func ParseTemplateKind(value string) (TemplateKind, bool) {
+	kind := TemplateKind(value)
+	return kind, kind == TemplateAccept || kind == TemplateDecline
+}
+
+// Template is a canned reply admins pick when accepting or declining a request.
+// Its text may contain placeholders such as {name} and {ticket}.
+type Template struct {
+	Name      string       `json:"name"`
+	Kind      TemplateKind `json:"kind"`
+	Text      string       `json:"text"`
+	CreatedBy int64        `json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// Job is a delayed action kept on disk until it ran, so it survives restarts.
+type Job struct {
+	ID        int64           `json:"id"`
+	Kind      string          `json:"kind"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	DueAt     time.Time       `json:"due_at"`
+	Attempts  int             `json:"attempts,omitempty"`
+	LastError string          `json:"last_error,omitempty"`
+}
+
+// MessageKind names a kind of bot message that is deleted automatically after its TTL.
+type MessageKind string
+
+const (
+	MessageConfirmation MessageKind = "confirmation"
+	MessageAccepted     MessageKind = "accepted"
+	MessageDeclined     MessageKind = "declined"
+)
+
+var MessageKinds = []MessageKind{MessageConfirmation, MessageAccepted, MessageDeclined}
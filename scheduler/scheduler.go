@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego"
+	"github.com/pureheroky/tg-golang-bot/models"
+	"github.com/pureheroky/tg-golang-bot/storage"
+)
+
+const (
+	// MaxAttempts is how many times a failing job runs before it is dropped.
+	MaxAttempts = 5
+	retryDelay  = 30 * time.Second
+)
+
+// Handler runs a job of one kind. A returned error makes the job retry later.
+type Handler func(bot *telego.Bot, job *models.Job) error
+
+// Scheduler runs delayed actions. Jobs are persisted before they are due and
+// removed once they succeed, so jobs pending during a restart run on the first
+// tick after the bot starts again.
+type Scheduler struct {
+	mu          sync.RWMutex
+	store       storage.JobStore
+	handlers    map[string]Handler
+	errorLogger *log.Logger
+}
+
+func New(store storage.JobStore, errorLogger *log.Logger) *Scheduler {
+	return &Scheduler{
+		store:       store,
+		handlers:    make(map[string]Handler),
+		errorLogger: errorLogger,
+	}
+}
+
+// Register sets the handler for jobs of the kind.
+func (s *Scheduler) Register(kind string, handler Handler) {
+	s.mu.Lock()
+	s.handlers[kind] = handler
+	s.mu.Unlock()
+}
+
+// Schedule stores a job that runs after delay with the payload encoded as JSON.
+func (s *Scheduler) Schedule(kind string, delay time.Duration, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s job: %w", kind, err)
+	}
+
+	return s.store.Add(&models.Job{
+		Kind:    kind,
+		Payload: raw,
+		DueAt:   time.Now().Add(delay),
+	})
+}
+
+// Run executes due jobs every interval until stop is closed. The first check
+// happens right away to catch up with jobs missed while the bot was down.
+func (s *Scheduler) Run(bot *telego.Bot, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.runDue(bot, time.Now())
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			s.runDue(bot, now)
+		}
+	}
+}
+
+func (s *Scheduler) runDue(bot *telego.Bot, now time.Time) {
+	for _, job := range s.store.Due(now) {
+		s.mu.RLock()
+		handler, ok := s.handlers[job.Kind]
+		s.mu.RUnlock()
+
+		if !ok {
+			s.errorLogger.Printf("Dropping job %d of unknown kind %q", job.ID, job.Kind)
+			s.remove(job)
+			continue
+		}
+
+		err := handler(bot, job)
+		if err == nil {
+			s.remove(job)
+			continue
+		}
+
+		job.Attempts++
+		job.LastError = err.Error()
+		if job.Attempts >= MaxAttempts {
+			s.errorLogger.Printf("Dropping %s job %d after %d attempts: %v", job.Kind, job.ID, job.Attempts, err)
+			s.remove(job)
+			continue
+		}
+
+		job.DueAt = now.Add(time.Duration(job.Attempts) * retryDelay)
+		if err := s.store.Update(job); err != nil {
+			s.errorLogger.Printf("Failed to reschedule job %d: %v", job.ID, err)
+		}
+	}
+}
+
+func (s *Scheduler) remove(job *models.Job) {
+	if err := s.store.Remove(job.ID); err != nil {
+		s.errorLogger.Printf("Failed to remove job %d: %v", job.ID, err)
+	}
+}
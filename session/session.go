@@ -0,0 +1,271 @@
+package session
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mymmrac/telego"
+)
+
+type State string
+
+// Session is the conversation state of a single chat inside a flow.
+// Handlers receive the session locked, so they may read and change it freely.
+type Session struct {
+	mu        sync.Mutex
+	ChatID    int64
+	Flow      string
+	State     State
+	Data      map[string]string
+	UpdatedAt time.Time
+	ended     bool
+}
+
+// Handler processes a message received while the session is in a given state.
+type Handler func(bot *telego.Bot, update telego.Update, s *Session)
+
+// Flow describes a multi-message conversation: the message handler for each
+// state and an optional callback run when a session expires.
+type Flow struct {
+	Name     string
+	Handlers map[State]Handler
+	OnExpire func(bot *telego.Bot, s *Session)
+}
+
+func (s *Session) Transition(state State) {
+	s.State = state
+}
+
+// End finishes the session once the current handler returns.
+func (s *Session) End() {
+	s.ended = true
+}
+
+func (s *Session) Int(key string) int {
+	value, _ := strconv.Atoi(s.Data[key])
+	return value
+}
+
+func (s *Session) SetInt(key string, value int) {
+	s.Data[key] = strconv.Itoa(value)
+}
+
+func (s *Session) Int64(key string) int64 {
+	value, _ := strconv.ParseInt(s.Data[key], 10, 64)
+	return value
+}
+
+func (s *Session) SetInt64(key string, value int64) {
+	s.Data[key] = strconv.FormatInt(value, 10)
+}
+
+// Manager keeps at most one active session per chat and dispatches messages to
+// the registered flows. Sessions idle for longer than the TTL are expired.
+type Manager struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	flows    map[string]*Flow
+	sessions map[int64]*Session
+}
+
+func NewManager(ttl time.Duration) *Manager {
+	return &Manager{
+		ttl:      ttl,
+		flows:    make(map[string]*Flow),
+		sessions: make(map[int64]*Session),
+	}
+}
+
+func (m *Manager) Register(flow *Flow) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.flows[flow.Name] = flow
+}
+
+// Start begins a new session in the chat, replacing any existing one, and
+// runs init on it before it becomes visible to other handlers.
+func (m *Manager) Start(chatID int64, flow string, state State, init func(s *Session)) {
+	s := &Session{
+		ChatID:    chatID,
+		Flow:      flow,
+		State:     state,
+		Data:      make(map[string]string),
+		UpdatedAt: time.Now(),
+	}
+	if init != nil {
+		init(s)
+	}
+
+	m.mu.Lock()
+	m.sessions[chatID] = s
+	m.mu.Unlock()
+}
+
+// StartIdle is Start for flows the bot begins on its own: it starts the session
+// only if the chat has none, so a conversation the user is in is never taken
+// over, and reports whether it did.
+func (m *Manager) StartIdle(chatID int64, flow string, state State, init func(s *Session)) bool {
+	s := &Session{
+		ChatID:    chatID,
+		Flow:      flow,
+		State:     state,
+		Data:      make(map[string]string),
+		UpdatedAt: time.Now(),
+	}
+	if init != nil {
+		init(s)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, busy := m.sessions[chatID]; busy {
+		return false
+	}
+	m.sessions[chatID] = s
+	return true
+}
+
+// Active reports the flow and state of the chat's session.
+func (m *Manager) Active(chatID int64) (string, State, bool) {
+	s := m.get(chatID)
+	if s == nil {
+		return "", "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return "", "", false
+	}
+	return s.Flow, s.State, true
+}
+
+// Update runs fn on the chat's session if it belongs to the given flow.
+func (m *Manager) Update(chatID int64, flow string, fn func(s *Session)) bool {
+	s := m.get(chatID)
+	if s == nil || s.Flow != flow {
+		return false
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return false
+	}
+	fn(s)
+	s.UpdatedAt = time.Now()
+	ended := s.ended
+	s.mu.Unlock()
+
+	if ended {
+		m.remove(chatID, s)
+	}
+	return true
+}
+
+// End removes the chat's session without running any handler.
+func (m *Manager) End(chatID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, chatID)
+}
+
+// Handle dispatches a message to the handler of the chat's current state.
+// It reports whether the message was consumed by a flow.
+func (m *Manager) Handle(bot *telego.Bot, update telego.Update) bool {
+	if update.Message == nil {
+		return false
+	}
+	chatID := update.Message.Chat.ID
+
+	s := m.get(chatID)
+	if s == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	flow := m.flows[s.Flow]
+	m.mu.Unlock()
+	if flow == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	handler, ok := flow.Handlers[s.State]
+	if s.ended || !ok {
+		s.mu.Unlock()
+		return false
+	}
+	handler(bot, update, s)
+	s.UpdatedAt = time.Now()
+	ended := s.ended
+	s.mu.Unlock()
+
+	if ended {
+		m.remove(chatID, s)
+	}
+	return true
+}
+
+// Run expires idle sessions every interval until stop is closed.
+func (m *Manager) Run(bot *telego.Bot, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			m.expire(bot, now)
+		}
+	}
+}
+
+func (m *Manager) expire(bot *telego.Bot, now time.Time) {
+	m.mu.Lock()
+	candidates := make([]*Session, 0)
+	for _, s := range m.sessions {
+		candidates = append(candidates, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range candidates {
+		s.mu.Lock()
+		if s.ended || now.Sub(s.UpdatedAt) < m.ttl {
+			s.mu.Unlock()
+			continue
+		}
+		s.ended = true
+
+		m.mu.Lock()
+		flow := m.flows[s.Flow]
+		m.mu.Unlock()
+		if flow != nil && flow.OnExpire != nil {
+			flow.OnExpire(bot, s)
+		}
+		s.mu.Unlock()
+
+		m.remove(s.ChatID, s)
+	}
+}
+
+func (m *Manager) get(chatID int64) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.sessions[chatID]
+}
+
+// remove deletes the session unless it was already replaced by a newer one.
+func (m *Manager) remove(chatID int64, s *Session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.sessions[chatID] == s {
+		delete(m.sessions, chatID)
+	}
+}
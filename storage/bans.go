@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+type BanStore interface {
+	// Get returns the active ban of the user, expired bans are ignored.
+	Get(userID int64) (*models.Ban, bool)
+	Ban(ban *models.Ban) error
+	// Unban lifts the ban and reports whether the user was banned.
+	Unban(userID int64) (bool, error)
+	List() []*models.Ban
+}
+
+// FileBanStore keeps the blocklist in a JSON file. Expired bans stay in the
+// file as a record until the user is banned again or unbanned.
+type FileBanStore struct {
+	mu   sync.RWMutex
+	path string
+	bans map[int64]*models.Ban
+}
+
+func NewFileBanStore(path string) (*FileBanStore, error) {
+	store := &FileBanStore{
+		path: path,
+		bans: make(map[int64]*models.Ban),
+	}
+	if err := readJSON(path, &store.bans); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileBanStore) Get(userID int64) (*models.Ban, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ban, ok := s.bans[userID]
+	if !ok || !ban.Active(time.Now()) {
+		return nil, false
+	}
+	copied := *ban
+	return &copied, true
+}
+
+func (s *FileBanStore) Ban(ban *models.Ban) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *ban
+	previous, existed := s.bans[ban.UserID]
+	s.bans[ban.UserID] = &copied
+	if err := writeJSON(s.path, s.bans); err != nil {
+		if existed {
+			s.bans[ban.UserID] = previous
+		} else {
+			delete(s.bans, ban.UserID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileBanStore) Unban(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.bans[userID]
+	if !existed {
+		return false, nil
+	}
+	delete(s.bans, userID)
+	if err := writeJSON(s.path, s.bans); err != nil {
+		s.bans[userID] = previous
+		return false, err
+	}
+	return previous.Active(time.Now()), nil
+}
+
+// List returns the active bans, newest first.
+func (s *FileBanStore) List() []*models.Ban {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	bans := make([]*models.Ban, 0, len(s.bans))
+	for _, ban := range s.bans {
+		if !ban.Active(now) {
+			continue
+		}
+		copied := *ban
+		bans = append(bans, &copied)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].CreatedAt.After(bans[j].CreatedAt)
+	})
+	return bans
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+type JobStore interface {
+	// Add stores the job, assigning it an ID.
+	Add(job *models.Job) error
+	Update(job *models.Job) error
+	Remove(id int64) error
+	// Due returns the jobs due at the given time, the earliest first.
+	Due(now time.Time) []*models.Job
+}
+
+type jobFile struct {
+	NextID int64         `json:"next_id"`
+	Jobs   []*models.Job `json:"jobs"`
+}
+
+// FileJobStore keeps scheduled jobs in memory and mirrors every change to a JSON file.
+type FileJobStore struct {
+	mu     sync.Mutex
+	path   string
+	nextID int64
+	jobs   map[int64]*models.Job
+}
+
+func NewFileJobStore(path string) (*FileJobStore, error) {
+	var file jobFile
+	if err := readJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	store := &FileJobStore{
+		path:   path,
+		nextID: file.NextID,
+		jobs:   make(map[int64]*models.Job, len(file.Jobs)),
+	}
+	for _, job := range file.Jobs {
+		store.jobs[job.ID] = job
+		if job.ID >= store.nextID {
+			store.nextID = job.ID + 1
+		}
+	}
+	if store.nextID == 0 {
+		store.nextID = 1
+	}
+
+	return store, nil
+}
+
+func (s *FileJobStore) Add(job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.ID = s.nextID
+	copied := *job
+	s.jobs[job.ID] = &copied
+	s.nextID++
+
+	if err := s.save(); err != nil {
+		delete(s.jobs, job.ID)
+		s.nextID--
+		return err
+	}
+	return nil
+}
+
+func (s *FileJobStore) Update(job *models.Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.jobs[job.ID]
+	if !ok {
+		return nil
+	}
+	copied := *job
+	s.jobs[job.ID] = &copied
+
+	if err := s.save(); err != nil {
+		s.jobs[job.ID] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileJobStore) Remove(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	delete(s.jobs, id)
+
+	if err := s.save(); err != nil {
+		s.jobs[id] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileJobStore) Due(now time.Time) []*models.Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]*models.Job, 0)
+	for _, job := range s.jobs {
+		if job.DueAt.After(now) {
+			continue
+		}
+		copied := *job
+		due = append(due, &copied)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DueAt.Before(due[j].DueAt)
+	})
+	return due
+}
+
+func (s *FileJobStore) save() error {
+	file := jobFile{
+		NextID: s.nextID,
+		Jobs:   make([]*models.Job, 0, len(s.jobs)),
+	}
+	for _, job := range s.jobs {
+		file.Jobs = append(file.Jobs, job)
+	}
+	sort.Slice(file.Jobs, func(i, j int) bool {
+		return file.Jobs[i].ID < file.Jobs[j].ID
+	})
+	return writeJSON(s.path, file)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+type RoleStore interface {
+	Get(userID int64) (models.Role, bool)
+	Set(userID int64, role models.Role) error
+	Remove(userID int64) error
+	List() map[int64]models.Role
+}
+
+// FileRoleStore keeps team roles in a JSON file.
+type FileRoleStore struct {
+	mu    sync.RWMutex
+	path  string
+	roles map[int64]models.Role
+}
+
+func NewFileRoleStore(path string) (*FileRoleStore, error) {
+	store := &FileRoleStore{
+		path:  path,
+		roles: make(map[int64]models.Role),
+	}
+	if err := readJSON(path, &store.roles); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileRoleStore) Get(userID int64) (models.Role, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	role, ok := s.roles[userID]
+	return role, ok
+}
+
+func (s *FileRoleStore) Set(userID int64, role models.Role) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.roles[userID]
+	s.roles[userID] = role
+	if err := writeJSON(s.path, s.roles); err != nil {
+		if existed {
+			s.roles[userID] = previous
+		} else {
+			delete(s.roles, userID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileRoleStore) Remove(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.roles[userID]
+	if !existed {
+		return nil
+	}
+	delete(s.roles, userID)
+	if err := writeJSON(s.path, s.roles); err != nil {
+		s.roles[userID] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileRoleStore) List() map[int64]models.Role {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := make(map[int64]models.Role, len(s.roles))
+	for userID, role := range s.roles {
+		roles[userID] = role
+	}
+	return roles
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+type TemplateStore interface {
+	Get(name string) (*models.Template, bool)
+	// Save adds the template or replaces the one with the same name.
+	Save(template *models.Template) error
+	// Remove deletes the template and reports whether it existed.
+	Remove(name string) (bool, error)
+	// List returns the templates of the kind, every template for an empty kind.
+	List(kind models.TemplateKind) []*models.Template
+}
+
+// FileTemplateStore keeps the reply templates in a JSON file, keyed by name.
+type FileTemplateStore struct {
+	mu        sync.RWMutex
+	path      string
+	templates map[string]*models.Template
+}
+
+func NewFileTemplateStore(path string) (*FileTemplateStore, error) {
+	store := &FileTemplateStore{
+		path:      path,
+		templates: make(map[string]*models.Template),
+	}
+	if err := readJSON(path, &store.templates); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *FileTemplateStore) Get(name string) (*models.Template, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	template, ok := s.templates[name]
+	if !ok {
+		return nil, false
+	}
+	copied := *template
+	return &copied, true
+}
+
+func (s *FileTemplateStore) Save(template *models.Template) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *template
+	previous, existed := s.templates[template.Name]
+	s.templates[template.Name] = &copied
+	if err := writeJSON(s.path, s.templates); err != nil {
+		if existed {
+			s.templates[template.Name] = previous
+		} else {
+			delete(s.templates, template.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileTemplateStore) Remove(name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.templates[name]
+	if !existed {
+		return false, nil
+	}
+	delete(s.templates, name)
+	if err := writeJSON(s.path, s.templates); err != nil {
+		s.templates[name] = previous
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the templates sorted by kind and name.
+func (s *FileTemplateStore) List(kind models.TemplateKind) []*models.Template {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	templates := make([]*models.Template, 0, len(s.templates))
+	for _, template := range s.templates {
+		if kind != "" && template.Kind != kind {
+			continue
+		}
+		copied := *template
+		templates = append(templates, &copied)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Kind != templates[j].Kind {
+			return templates[i].Kind < templates[j].Kind
+		}
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
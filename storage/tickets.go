@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+var ErrTicketNotFound = errors.New("ticket not found")
+
+type TicketStore interface {
+	Create(ticket *models.Ticket) error
+	Get(id int64) (*models.Ticket, error)
+	Update(ticket *models.Ticket) error
+	// Modify atomically applies fn to the stored ticket and saves the result.
+	Modify(id int64, fn func(ticket *models.Ticket) error) (*models.Ticket, error)
+	// FindByMessage returns the ticket whose conversation contains the message.
+	FindByMessage(chatID int64, messageID int) (*models.Ticket, error)
+	List() ([]*models.Ticket, error)
+}
+
+type ticketFile struct {
+	NextID  int64            `json:"next_id"`
+	Tickets []*models.Ticket `json:"tickets"`
+}
+
+// FileTicketStore keeps tickets in memory and mirrors every change to a JSON file.
+type FileTicketStore struct {
+	mu      sync.RWMutex
+	path    string
+	nextID  int64
+	tickets map[int64]*models.Ticket
+}
+
+func NewFileTicketStore(path string) (*FileTicketStore, error) {
+	var file ticketFile
+	if err := readJSON(path, &file); err != nil {
+		return nil, err
+	}
+
+	store := &FileTicketStore{
+		path:    path,
+		nextID:  file.NextID,
+		tickets: make(map[int64]*models.Ticket, len(file.Tickets)),
+	}
+	for _, ticket := range file.Tickets {
+		store.tickets[ticket.ID] = ticket
+		if ticket.ID >= store.nextID {
+			store.nextID = ticket.ID + 1
+		}
+	}
+	if store.nextID == 0 {
+		store.nextID = 1
+	}
+
+	return store, nil
+}
+
+func (s *FileTicketStore) Create(ticket *models.Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ticket.ID = s.nextID
+	s.tickets[ticket.ID] = ticket.Clone()
+	s.nextID++
+
+	if err := s.save(); err != nil {
+		delete(s.tickets, ticket.ID)
+		s.nextID--
+		return err
+	}
+	return nil
+}
+
+func (s *FileTicketStore) Get(id int64) (*models.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ticket, ok := s.tickets[id]
+	if !ok {
+		return nil, ErrTicketNotFound
+	}
+	return ticket.Clone(), nil
+}
+
+func (s *FileTicketStore) Update(ticket *models.Ticket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.tickets[ticket.ID]
+	if !ok {
+		return ErrTicketNotFound
+	}
+	s.tickets[ticket.ID] = ticket.Clone()
+
+	if err := s.save(); err != nil {
+		s.tickets[ticket.ID] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileTicketStore) Modify(id int64, fn func(ticket *models.Ticket) error) (*models.Ticket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.tickets[id]
+	if !ok {
+		return nil, ErrTicketNotFound
+	}
+
+	ticket := previous.Clone()
+	if err := fn(ticket); err != nil {
+		return nil, err
+	}
+	ticket.ID = id
+	s.tickets[id] = ticket
+
+	if err := s.save(); err != nil {
+		s.tickets[id] = previous
+		return nil, err
+	}
+	return ticket.Clone(), nil
+}
+
+func (s *FileTicketStore) FindByMessage(chatID int64, messageID int) (*models.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ticket := range s.tickets {
+		if ticket.HasMessage(chatID, messageID) {
+			return ticket.Clone(), nil
+		}
+	}
+	return nil, ErrTicketNotFound
+}
+
+// List returns all tickets ordered by ID.
+func (s *FileTicketStore) List() ([]*models.Ticket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted(), nil
+}
+
+func (s *FileTicketStore) sorted() []*models.Ticket {
+	tickets := make([]*models.Ticket, 0, len(s.tickets))
+	for _, ticket := range s.tickets {
+		tickets = append(tickets, ticket.Clone())
+	}
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].ID < tickets[j].ID
+	})
+	return tickets
+}
+
+func (s *FileTicketStore) save() error {
+	return writeJSON(s.path, ticketFile{
+		NextID:  s.nextID,
+		Tickets: s.sorted(),
+	})
+}
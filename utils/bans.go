@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+func FormatBan(ban *models.Ban) string {
+	message := fmt.Sprintf("<code>%d</code> | since %s", ban.UserID, ban.CreatedAt.Format(ticketTimeLayout))
+	if expiresAt, ok := ban.Expiry(); ok {
+		message += fmt.Sprintf(" | until <b>%s</b>", expiresAt.Format(ticketTimeLayout))
+	} else {
+		message += " | <b>permanent</b>"
+	}
+	if ban.Reason != "" {
+		message += fmt.Sprintf("\n<i>%s</i>", html.EscapeString(ban.Reason))
+	}
+	return message
+}
+
+func FormatBanList(bans []*models.Ban) string {
+	if len(bans) == 0 {
+		return "Nobody is banned."
+	}
+
+	message := fmt.Sprintf("<b>Banned users</b> (%d)\n", len(bans))
+	for _, ban := range bans {
+		message += "\n" + FormatBan(ban) + "\n"
+	}
+	return message
+}
@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+// ExportFilter selects the tickets to export. Zero fields don't filter.
+type ExportFilter struct {
+	Status models.TicketStatus
+	From   time.Time
+	// To is exclusive.
+	To time.Time
+}
+
+type exportedTicket struct {
+	ID          int64               `json:"id"`
+	Status      models.TicketStatus `json:"status"`
+	RequesterID int64               `json:"requester_id"`
+	Username    string              `json:"username"`
+	Name        string              `json:"name"`
+	Direction   string              `json:"direction"`
+	Category    string              `json:"category"`
+	Description string              `json:"description"`
+	Contact     string              `json:"contact"`
+	Attachments int                 `json:"attachments"`
+	Decision    string              `json:"decision"`
+	Assignee    string              `json:"assignee"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+var exportColumns = []string{
+	"id", "status", "requester_id", "username", "name", "direction", "category", "description",
+	"contact", "attachments", "decision", "assignee", "created_at", "updated_at",
+}
+
+// FilterExportTickets returns the tickets matching the filter, oldest first.
+func FilterExportTickets(tickets []*models.Ticket, filter ExportFilter) []*models.Ticket {
+	output := make([]*models.Ticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		if filter.Status != "" && ticket.Status != filter.Status {
+			continue
+		}
+		if !filter.From.IsZero() && ticket.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !ticket.CreatedAt.Before(filter.To) {
+			continue
+		}
+		output = append(output, ticket)
+	}
+	return output
+}
+
+func exportTicket(ticket *models.Ticket) exportedTicket {
+	return exportedTicket{
+		ID:          ticket.ID,
+		Status:      ticket.Status,
+		RequesterID: ticket.RequesterID,
+		Username:    ticket.Username,
+		Name:        ticket.Fields.Name,
+		Direction:   ticket.Fields.Direction,
+		Category:    ticket.Category,
+		Description: ticket.Fields.Description,
+		Contact:     ticket.Fields.Contact,
+		Attachments: len(ticket.Attachments),
+		Decision:    ticket.Decision,
+		Assignee:    ticket.AssigneeName,
+		CreatedAt:   ticket.CreatedAt,
+		UpdatedAt:   ticket.UpdatedAt,
+	}
+}
+
+func ExportTicketsJSON(tickets []*models.Ticket) ([]byte, error) {
+	rows := make([]exportedTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		rows = append(rows, exportTicket(ticket))
+	}
+	return json.MarshalIndent(rows, "", "  ")
+}
+
+func ExportTicketsCSV(tickets []*models.Ticket) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	for _, ticket := range tickets {
+		row := exportTicket(ticket)
+		record := []string{
+			strconv.FormatInt(row.ID, 10),
+			string(row.Status),
+			strconv.FormatInt(row.RequesterID, 10),
+			csvText(row.Username),
+			csvText(row.Name),
+			csvText(row.Direction),
+			csvText(row.Category),
+			csvText(row.Description),
+			csvText(row.Contact),
+			strconv.Itoa(row.Attachments),
+			csvText(row.Decision),
+			csvText(row.Assignee),
+			row.CreatedAt.Format(time.RFC3339),
+			row.UpdatedAt.Format(time.RFC3339),
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// csvText keeps spreadsheets from running text typed by requesters as a
+// formula, prefixing cells that would start one with a quote.
+func csvText(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pureheroky/tg-golang-bot/httpclient"
+)
+
+// getJSONData fetches the URL and decodes its JSON body into target.
+func getJSONData(ctx context.Context, client *httpclient.Client, url string, target interface{}) error {
+	resp, err := client.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/github"
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+// DataRefresher keeps the GitHub data of the data store up to date. Refreshes
+// never overlap: one requested while another is running waits for it.
+type DataRefresher struct {
+	mu          sync.Mutex
+	dataStore   *models.DataStore
+	client      *github.Client
+	username    string
+	commitLimit int
+	errorLogger *log.Logger
+}
+
+func NewDataRefresher(dataStore *models.DataStore, client *github.Client, username string, commitLimit int, errorLogger *log.Logger) *DataRefresher {
+	return &DataRefresher{
+		dataStore:   dataStore,
+		client:      client,
+		username:    username,
+		commitLimit: commitLimit,
+		errorLogger: errorLogger,
+	}
+}
+
+// Refresh fetches the projects and commits again and replaces the cached ones.
+func (r *DataRefresher) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return LoadData(ctx, r.dataStore, r.client, r.username, r.commitLimit, r.errorLogger)
+}
+
+// Run refreshes the data every interval until stop is closed, which also
+// aborts a refresh in progress.
+func (r *DataRefresher) Run(interval time.Duration, stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// LoadData already logged the error, the next tick tries again.
+			_ = r.Refresh(ctx)
+		}
+	}
+}
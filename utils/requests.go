@@ -0,0 +1,249 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pureheroky/tg-golang-bot/limits"
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+var contactPattern = regexp.MustCompile(`(?i)(@[a-z0-9_]{4,}|[^\s@]+@[^\s@]+\.[a-z]{2,}|\+?\d[\d\s\-()]{6,}|https?://\S+|t\.me/\S+)`)
+
+var (
+	numberedLinePattern = regexp.MustCompile(`^\s*([1-4])\s*[.)]\s*(.*)$`)
+	labeledLinePattern  = regexp.MustCompile(`(?i)^\s*(name|direction|task direction|type|description|task|contacts?)\s*[:\-]\s*(.*)$`)
+)
+
+// RequestSteps lists the wizard steps asking for request fields, in order.
+var RequestSteps = []models.RequestStep{models.StepName, models.StepDirection, models.StepDescription, models.StepContact}
+
+var requestLabels = map[string]models.RequestStep{
+	"name":           models.StepName,
+	"direction":      models.StepDirection,
+	"task direction": models.StepDirection,
+	"type":           models.StepDirection,
+	"description":    models.StepDescription,
+	"task":           models.StepDescription,
+	"contact":        models.StepContact,
+	"contacts":       models.StepContact,
+}
+
+// GetRequestStepPrompt returns the question asked by the request wizard at the given step.
+func GetRequestStepPrompt(step models.RequestStep) string {
+	switch step {
+	case models.StepName:
+		return "<b>1/4.</b> What is <b>your name</b>?"
+	case models.StepDirection:
+		return "<b>2/4.</b> What is the <b>direction of the task</b>? (web-development, python apps, bots etc.)"
+	case models.StepDescription:
+		return "<b>3/4.</b> Please <b>describe the task</b>. You can also attach documents, photos or voice messages."
+	case models.StepContact:
+		return "<b>4/4.</b> How can I <b>contact you</b>? (telegram @username, email, phone or link)"
+	default:
+		return ""
+	}
+}
+
+// ValidateRequestField checks the answer given at a wizard step.
+func ValidateRequestField(step models.RequestStep, value string) error {
+	length := utf8.RuneCountInString(strings.TrimSpace(value))
+
+	switch step {
+	case models.StepName:
+		if length < 2 || length > 64 {
+			return errors.New("name must be between 2 and 64 characters")
+		}
+	case models.StepDirection:
+		if length < 2 || length > 100 {
+			return errors.New("direction must be between 2 and 100 characters")
+		}
+	case models.StepDescription:
+		if length < 10 || length > 3000 {
+			return errors.New("description must be between 10 and 3000 characters")
+		}
+	case models.StepContact:
+		if length > 200 || !contactPattern.MatchString(value) {
+			return errors.New("contact must be a telegram @username, email, phone number or link")
+		}
+	default:
+		return errors.New("unknown request step")
+	}
+
+	return nil
+}
+
+// ParseRequestText extracts request fields from a message written after the
+// sample: numbered points ("1. John") or labels ("Name: John"). Lines that start
+// neither continue the previous field, so descriptions may span several lines.
+// Numbered points must go in order, which keeps a numbered list inside the
+// description from being taken for the sample's points. It returns the fields
+// and the steps that were found.
+func ParseRequestText(text string) (models.RequestFields, []models.RequestStep) {
+	values := make(map[models.RequestStep][]string)
+	found := make([]models.RequestStep, 0, len(RequestSteps))
+	var current models.RequestStep
+	lastNumber := 0
+
+	for _, line := range strings.Split(text, "\n") {
+		step, value, number := parseRequestLine(line)
+		if step != "" && number != 0 && number <= lastNumber {
+			step = ""
+		}
+		if step != "" && values[step] != nil {
+			step = ""
+		}
+
+		if step == "" {
+			if current != "" {
+				values[current] = append(values[current], line)
+			}
+			continue
+		}
+
+		if number != 0 {
+			lastNumber = number
+		}
+		current = step
+		values[step] = []string{value}
+		found = append(found, step)
+	}
+
+	var fields models.RequestFields
+	for step, lines := range values {
+		SetRequestField(&fields, step, strings.Join(lines, "\n"))
+	}
+	return fields, found
+}
+
+func parseRequestLine(line string) (models.RequestStep, string, int) {
+	if match := numberedLinePattern.FindStringSubmatch(line); match != nil {
+		number := int(match[1][0] - '0')
+		return RequestSteps[number-1], match[2], number
+	}
+	if match := labeledLinePattern.FindStringSubmatch(line); match != nil {
+		return requestLabels[strings.ToLower(match[1])], match[2], 0
+	}
+	return "", "", 0
+}
+
+// GetRequestField returns the answer stored for a wizard step.
+func GetRequestField(fields models.RequestFields, step models.RequestStep) string {
+	switch step {
+	case models.StepName:
+		return fields.Name
+	case models.StepDirection:
+		return fields.Direction
+	case models.StepDescription:
+		return fields.Description
+	case models.StepContact:
+		return fields.Contact
+	default:
+		return ""
+	}
+}
+
+// MatchCategory finds the category named by the direction answer, comparing
+// with the category key and title regardless of case.
+func MatchCategory(categories []models.Category, direction string) (models.Category, bool) {
+	direction = strings.TrimSpace(direction)
+	for _, category := range categories {
+		if strings.EqualFold(direction, category.Key) || strings.EqualFold(direction, category.Title) {
+			return category, true
+		}
+	}
+	return models.Category{}, false
+}
+
+// SetRequestField stores the answer given at a wizard step.
+func SetRequestField(fields *models.RequestFields, step models.RequestStep, value string) {
+	value = strings.TrimSpace(value)
+
+	switch step {
+	case models.StepName:
+		fields.Name = value
+	case models.StepDirection:
+		fields.Direction = value
+	case models.StepDescription:
+		fields.Description = value
+	case models.StepContact:
+		fields.Contact = value
+	}
+}
+
+// FormatRequestFields renders request fields as HTML.
+func FormatRequestFields(fields models.RequestFields) string {
+	return fmt.Sprintf(
+		"<b>Name:</b> %s\n<b>Direction:</b> %s\n<b>Description:</b>\n%s\n<b>Contact:</b> %s",
+		html.EscapeString(fields.Name),
+		html.EscapeString(fields.Direction),
+		html.EscapeString(fields.Description),
+		html.EscapeString(fields.Contact),
+	)
+}
+
+// FormatAttachments renders a short summary of request attachments like
+// "2 photos, 1 document", or an empty string when there are none.
+func FormatAttachments(attachments []models.Attachment) string {
+	counts := make(map[models.AttachmentType]int)
+	for _, attachment := range attachments {
+		counts[attachment.Type]++
+	}
+
+	parts := make([]string, 0, len(counts))
+	for _, attachmentType := range []models.AttachmentType{models.AttachmentDocument, models.AttachmentPhoto, models.AttachmentVoice} {
+		count := counts[attachmentType]
+		if count == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%d %s", count, attachmentType)
+		if count > 1 {
+			part += "s"
+		}
+		parts = append(parts, part)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// FormatLimitDenial tells the user why the request can't be sent and when to come back.
+func FormatLimitDenial(denial *limits.Denial, now time.Time) string {
+	switch denial.Reason {
+	case limits.ReasonCooldown:
+		return fmt.Sprintf("Your last request was declined recently.\n\nYou can send a new one in <b>%s</b> (at %s).", formatWait(denial.RetryAt.Sub(now)), denial.RetryAt.Format(ticketTimeLayout))
+	case limits.ReasonRateLimit:
+		return fmt.Sprintf("You have sent too many requests.\n\nYou can send a new one in <b>%s</b> (at %s).", formatWait(denial.RetryAt.Sub(now)), denial.RetryAt.Format(ticketTimeLayout))
+	case limits.ReasonDuplicate:
+		return "This request was already sent.\n\nI'll answer it as soon as possible, there is no need to send it again."
+	default:
+		return "You can't send a request right now, please try again later."
+	}
+}
+
+// formatWait renders a duration as "2h 15m", rounding up to whole minutes.
+func formatWait(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%dh", minutes/60)
+	}
+	return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+}
+
+// RequestFieldsText renders request fields as plain text.
+func RequestFieldsText(fields models.RequestFields) string {
+	return fmt.Sprintf(
+		"Name: %s\nDirection: %s\nDescription:\n%s\nContact: %s",
+		fields.Name, fields.Direction, fields.Description, fields.Contact,
+	)
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+const statsCommentLimit = 5
+
+var statsStatuses = []models.TicketStatus{
+	models.TicketNew,
+	models.TicketAccepted,
+	models.TicketDeclined,
+	models.TicketClosed,
+	models.TicketWithdrawn,
+}
+
+// FormatRating renders a rating as stars, e.g. ★★★★☆ for 4.
+func FormatRating(rating int) string {
+	rating = max(0, min(rating, models.MaxRating))
+	return strings.Repeat("★", rating) + strings.Repeat("☆", models.MaxRating-rating)
+}
+
+// FormatStats summarizes the tickets and the feedback their requesters left.
+func FormatStats(tickets []*models.Ticket) string {
+	counts := make(map[models.TicketStatus]int, len(statsStatuses))
+	ratings := make([]int, models.MaxRating+1)
+	asked, rated, total := 0, 0, 0
+	commented := make([]*models.Ticket, 0)
+
+	for _, ticket := range tickets {
+		counts[ticket.Status]++
+		if ticket.Feedback == nil {
+			continue
+		}
+		asked++
+		if rating := ticket.Feedback.Rating; rating > 0 && rating <= models.MaxRating {
+			ratings[rating]++
+			rated++
+			total += rating
+		}
+		if ticket.Feedback.Comment != "" {
+			commented = append(commented, ticket)
+		}
+	}
+
+	message := fmt.Sprintf("<b>Statistics</b>\n\nRequests: <b>%d</b>\n", len(tickets))
+	for _, status := range statsStatuses {
+		message += fmt.Sprintf("%s: %d\n", status, counts[status])
+	}
+
+	message += "\n<b>Feedback</b>\n"
+	if asked == 0 {
+		return message + "Nobody was asked for feedback yet."
+	}
+	message += fmt.Sprintf("Rated: <b>%d</b> of %d asked\n", rated, asked)
+	if rated > 0 {
+		message += fmt.Sprintf("Average: <b>%.1f</b> of %d\n\n", float64(total)/float64(rated), models.MaxRating)
+		for rating := models.MaxRating; rating >= 1; rating-- {
+			message += fmt.Sprintf("%s %d\n", FormatRating(rating), ratings[rating])
+		}
+	}
+
+	if len(commented) > 0 {
+		ratedAt := func(ticket *models.Ticket) time.Time {
+			if ticket.Feedback.RatedAt == nil {
+				return time.Time{}
+			}
+			return *ticket.Feedback.RatedAt
+		}
+		sort.SliceStable(commented, func(i, j int) bool {
+			return ratedAt(commented[i]).After(ratedAt(commented[j]))
+		})
+		message += "\n<b>Latest comments</b>\n"
+		for _, ticket := range commented[:min(len(commented), statsCommentLimit)] {
+			message += fmt.Sprintf("\n<b>#%d</b> %s\n<i>%s</i>\n", ticket.ID, FormatRating(ticket.Feedback.Rating), html.EscapeString(ticket.Feedback.Comment))
+		}
+	}
+
+	return message
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+// TemplatePlaceholders lists the placeholders FillTemplate replaces.
+var TemplatePlaceholders = []string{"{name}", "{ticket}", "{direction}", "{contact}", "{username}", "{category}"}
+
+// FillTemplate replaces the placeholders in the template text with the values
+// of the stored request.
+func FillTemplate(text string, ticket *models.Ticket) string {
+	return strings.NewReplacer(
+		"{name}", ticket.Fields.Name,
+		"{ticket}", strconv.FormatInt(ticket.ID, 10),
+		"{direction}", ticket.Fields.Direction,
+		"{contact}", ticket.Fields.Contact,
+		"{username}", ticket.Username,
+		"{category}", ticket.Category,
+	).Replace(text)
+}
+
+func FormatTemplateList(templates []*models.Template) string {
+	if len(templates) == 0 {
+		return "There are no templates yet. Add one with /template add &lt;accept|decline&gt; &lt;name&gt; &lt;text&gt;"
+	}
+
+	message := fmt.Sprintf("<b>Templates</b> (%d)\n", len(templates))
+	for _, template := range templates {
+		message += fmt.Sprintf("\n<code>%s</code> | <b>%s</b>\n<i>%s</i>\n", html.EscapeString(template.Name), template.Kind, html.EscapeString(template.Text))
+	}
+	message += "\nPlaceholders: " + strings.Join(TemplatePlaceholders, ", ")
+	return message
+}
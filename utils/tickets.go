@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pureheroky/tg-golang-bot/models"
+)
+
+const ticketTimeLayout = "2006-01-02 15:04"
+
+// FilterTickets returns the tickets matching the status (empty for any) and the
+// case-insensitive query, newest first.
+func FilterTickets(tickets []*models.Ticket, status models.TicketStatus, query string) []*models.Ticket {
+	query = strings.ToLower(strings.TrimSpace(query))
+	output := make([]*models.Ticket, 0, len(tickets))
+
+	for i := len(tickets) - 1; i >= 0; i-- {
+		ticket := tickets[i]
+		if status != "" && ticket.Status != status {
+			continue
+		}
+		if query != "" && !ticketMatches(ticket, query) {
+			continue
+		}
+		output = append(output, ticket)
+	}
+
+	return output
+}
+
+func ticketMatches(ticket *models.Ticket, query string) bool {
+	haystack := strings.ToLower(strings.Join([]string{
+		fmt.Sprint(ticket.ID),
+		ticket.Username,
+		ticket.Text,
+		ticket.Decision,
+	}, "\n"))
+	return strings.Contains(haystack, query)
+}
+
+// GetTicketTitle returns a short one-line description of the ticket.
+func GetTicketTitle(ticket *models.Ticket) string {
+	title := ticket.Fields.Name
+	if title == "" {
+		title = ticket.Username
+	}
+	if ticket.Fields.Direction != "" {
+		title += " · " + ticket.Fields.Direction
+	}
+	return fmt.Sprintf("#%d %s [%s]", ticket.ID, title, ticket.Status)
+}
+
+func FormatTicketListPage(tickets []*models.Ticket, filter models.InboxFilter, pageSize int) string {
+	header := "<b>Requests</b>"
+	if filter.Status != "" {
+		header += fmt.Sprintf(" | status: <b>%s</b>", filter.Status)
+	}
+	if filter.Query != "" {
+		header += fmt.Sprintf(" | search: <code>%s</code>", html.EscapeString(filter.Query))
+	}
+
+	if len(tickets) == 0 {
+		return header + "\n\nNo requests found."
+	}
+
+	start := filter.Page * pageSize
+	end := start + pageSize
+	if end > len(tickets) {
+		end = len(tickets)
+	}
+	pages := (len(tickets) + pageSize - 1) / pageSize
+
+	message := fmt.Sprintf("%s\nPage %d/%d, total %d\n\n", header, filter.Page+1, pages, len(tickets))
+	for _, ticket := range tickets[start:end] {
+		message += fmt.Sprintf("<b>%s</b>\n<i>%s</i>\n\n", html.EscapeString(GetTicketTitle(ticket)), ticket.CreatedAt.Format(ticketTimeLayout))
+	}
+
+	return message
+}
+
+func FormatTicketDetails(ticket *models.Ticket) string {
+	message := fmt.Sprintf(
+		"<b>Request #%d</b>\n\nStatus: <b>%s</b>\nFrom: <code>%s</code> | <code>%d</code>\nCreated: %s\nUpdated: %s\n\n",
+		ticket.ID,
+		ticket.Status,
+		html.EscapeString(ticket.Username),
+		ticket.RequesterID,
+		ticket.CreatedAt.Format(ticketTimeLayout),
+		ticket.UpdatedAt.Format(ticketTimeLayout),
+	)
+
+	if ticket.Category != "" {
+		message += fmt.Sprintf("Category: #%s\n", html.EscapeString(ticket.Category))
+	}
+	if ticket.AssigneeID != 0 {
+		message += fmt.Sprintf("Claimed by: %s\n", html.EscapeString(ticket.AssigneeName))
+	}
+	if ticket.Category != "" || ticket.AssigneeID != 0 {
+		message += "\n"
+	}
+
+	message += formatTicketBody(ticket)
+
+	if ticket.Decision != "" {
+		message += fmt.Sprintf("\n\n<b>Decision:</b>\n%s", html.EscapeString(ticket.Decision))
+	}
+
+	if ticket.Feedback != nil && ticket.Feedback.Rating > 0 {
+		message += fmt.Sprintf("\n\n<b>Feedback:</b> %s", FormatRating(ticket.Feedback.Rating))
+		if ticket.Feedback.Comment != "" {
+			message += fmt.Sprintf("\n<i>%s</i>", html.EscapeString(ticket.Feedback.Comment))
+		}
+	}
+
+	return message
+}
+
+// requesterStatusLabels describe ticket statuses in words a requester understands.
+var requesterStatusLabels = map[models.TicketStatus]string{
+	models.TicketNew:       "waiting for review",
+	models.TicketAccepted:  "accepted",
+	models.TicketDeclined:  "declined",
+	models.TicketClosed:    "closed",
+	models.TicketWithdrawn: "withdrawn",
+}
+
+// FormatRequesterTickets renders the requester's own tickets, newest first,
+// showing at most limit of them.
+func FormatRequesterTickets(tickets []*models.Ticket, limit int) string {
+	if len(tickets) == 0 {
+		return "You have not sent any requests yet."
+	}
+
+	message := "<b>Your requests</b>\n\n"
+	if len(tickets) > limit {
+		message = fmt.Sprintf("<b>Your requests</b> (last %d of %d)\n\n", limit, len(tickets))
+		tickets = tickets[:limit]
+	}
+
+	for _, ticket := range tickets {
+		message += fmt.Sprintf("<b>#%d</b>", ticket.ID)
+		if ticket.Fields.Direction != "" {
+			message += " " + html.EscapeString(ticket.Fields.Direction)
+		}
+		message += fmt.Sprintf(" | <b>%s</b>\nSent: %s\nUpdated: %s\n",
+			requesterStatusLabels[ticket.Status],
+			ticket.CreatedAt.Format(ticketTimeLayout),
+			ticket.UpdatedAt.Format(ticketTimeLayout),
+		)
+		if ticket.Decision != "" {
+			message += fmt.Sprintf("Developer message: <i>%s</i>\n", html.EscapeString(ticket.Decision))
+		}
+		message += "\n"
+	}
+
+	for _, ticket := range tickets {
+		if ticket.Status == models.TicketNew {
+			message += "<i>To fix a pending request, just edit your answer message in this chat.</i>"
+			break
+		}
+	}
+
+	return message
+}
+
+// FormatAdminRequest renders the copy of a request sent to admins.
+func FormatAdminRequest(ticket *models.Ticket) string {
+	message := fmt.Sprintf(
+		"Request <b>#%d</b> from <code>%s</code> | <code>%d</code>\n",
+		ticket.ID,
+		html.EscapeString(ticket.Username),
+		ticket.RequesterID,
+	)
+	if ticket.Category != "" {
+		message += "#" + html.EscapeString(ticket.Category) + "\n"
+	}
+	message += fmt.Sprintf("\n%s\n\nStatus: <b>%s</b>", formatTicketBody(ticket), ticket.Status)
+
+	if ticket.EditedAt != nil {
+		message += fmt.Sprintf("\n<i>Edited by the requester at %s</i>", ticket.EditedAt.Format(ticketTimeLayout))
+	}
+	if ticket.AssigneeID != 0 {
+		message += fmt.Sprintf("\nClaimed by: <b>%s</b>", html.EscapeString(ticket.AssigneeName))
+	}
+	if ticket.Decision != "" {
+		message += fmt.Sprintf("\n<b>Decision:</b>\n%s", html.EscapeString(ticket.Decision))
+	}
+	if ticket.Status == models.TicketAccepted {
+		message += "\n\n<i>Reply to this message to write to the requester.</i>"
+	}
+
+	return message
+}
+
+// formatTicketBody renders the structured request, falling back to the raw text
+// of tickets created before requests had fields.
+func formatTicketBody(ticket *models.Ticket) string {
+	body := html.EscapeString(ticket.Text)
+	if ticket.Fields != (models.RequestFields{}) {
+		body = FormatRequestFields(ticket.Fields)
+	}
+
+	if attachments := FormatAttachments(ticket.Attachments); attachments != "" {
+		body += fmt.Sprintf("\n<b>Attachments:</b> %s", attachments)
+	}
+	return body
+}
@@ -1,139 +1,24 @@
 package utils
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
+	"html"
 	"log"
-	"net/http"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
-	"sync"
+	"time"
 
+	"github.com/pureheroky/tg-golang-bot/github"
+	"github.com/pureheroky/tg-golang-bot/httpclient"
 	"github.com/pureheroky/tg-golang-bot/models"
 )
 
-func makeRequest(method, url, token string, body []byte) ([]byte, error) {
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
-	if token != "" {
-		req.Header.Set("Authorization", "token "+token)
-	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return responseBody, nil
-}
-
-func getJSONData(url, token string, target interface{}) error {
-	response, err := makeRequest("GET", url, token, nil)
-	if err != nil {
-		return err
-	}
-
-	err = json.Unmarshal(response, target)
-	if err != nil {
-		return fmt.Errorf("failed to unmarshal JSON response: %w", err)
-	}
-
-	return nil
-}
-
-func GetGitConcurrently(apiUrl string, username string, token string, dataStore *models.DataStore) (map[string][]map[string]string, error) {
-	projects := dataStore.ProjectsData
-	projectNames := []string{}
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
-	output := make(map[string][]map[string]string)
-
-	if len(dataStore.GitData) == 0 {
-		for _, value := range projects {
-			if name, ok := value["name"].(string); ok {
-				projectNames = append(projectNames, name)
-			}
-		}
-
-		for _, repoName := range projectNames {
-			wg.Add(1)
-
-			go func(repoName string) {
-				defer wg.Done()
-				url := fmt.Sprintf("%s/repos/%s/%s/commits", apiUrl, username, repoName)
-				var data []map[string]interface{}
-
-				err := getJSONData(url, token, &data)
-				if err != nil {
-					log.Printf("Error fetching commits for %s: %v", repoName, err)
-					return
-				}
-
-				mu.Lock()
-				defer mu.Unlock()
-
-				for index, val := range data {
-					if index >= 5 {
-						break
-					}
-					commit, ok := val["commit"].(map[string]interface{})
-					if !ok {
-						continue
-					}
-					authorMap, ok := commit["author"].(map[string]interface{})
-					if !ok {
-						continue
-					}
-					committerMap, ok := commit["committer"].(map[string]interface{})
-					if !ok {
-						continue
-					}
-					authorName, _ := authorMap["name"].(string)
-					message, _ := commit["message"].(string)
-					date, _ := committerMap["date"].(string)
-
-					if output[repoName] == nil {
-						output[repoName] = make([]map[string]string, 0)
-					}
-
-					output[repoName] = append(output[repoName], map[string]string{
-						"author":  authorName,
-						"message": message,
-						"date":    date,
-					})
-				}
-			}(repoName)
-		}
-
-		wg.Wait()
-		dataStore.GitData = output
-	} else {
-		output = dataStore.GitData
-	}
-
-	return output, nil
-}
-
-func Request(text string, id int64, username string, dataStore *models.DataStore) {
-	dataStore.RequestData = []string{username, fmt.Sprint(id), text}
-}
-
-func GetSkills(url string) ([]string, error) {
+func GetSkills(ctx context.Context, client *httpclient.Client, url string) ([]string, error) {
 	var responseObj models.SkillsResponse
-	err := getJSONData(url, "", &responseObj)
+	err := getJSONData(ctx, client, url, &responseObj)
 	if err != nil {
 		log.Printf("Error fetching skills data: %v", err)
 		return nil, err
@@ -155,56 +40,26 @@ func GetSkills(url string) ([]string, error) {
 	return skills, nil
 }
 
-func GetProjects(apiUrl string, username string, token string, dataStore *models.DataStore) (map[int][]string, error) {
-	output := make(map[int][]string)
-
-	dataUrl := fmt.Sprintf("%s/users/%s/repos", apiUrl, username)
-	var data []map[string]interface{}
-
-	if len(dataStore.ProjectsData) == 0 {
-		err := getJSONData(dataUrl, token, &data)
-		if err != nil {
-			log.Printf("Error fetching projects data: %v", err)
-			return nil, err
-		}
-		dataStore.ProjectsData = data
-	} else {
-		data = dataStore.ProjectsData
-	}
-
-	for index, value := range data {
-		id, _ := value["node_id"].(string)
-		name, _ := value["name"].(string)
-		url, _ := value["url"].(string)
-		createdAt, _ := value["created_at"].(string)
-		defaultBranch, _ := value["default_branch"].(string)
-		language, _ := value["language"].(string)
-
-		output[index] = []string{name, id, url, language, createdAt, defaultBranch}
-	}
-
-	return output, nil
-}
-
-func FormatProjectMessage(project []string) string {
-	if len(project) < 6 {
-		return "Invalid project data."
+func FormatProjectMessage(project github.Repository) string {
+	language := project.Language
+	if language == "" {
+		language = "-"
 	}
 	return fmt.Sprintf(
 		`
 <b><i>Title: <code>%s</code></i></b>
 <b>ID: %s</b>
-<b>URL: <a href='https://github.com/pureheroky/%s'>link</a></b>
+<b>URL: <a href='%s'>link</a></b>
 <b>Language: %s</b>
 <b>Creation date: %s</b>
 <b>Default branch: %s</b>
 `,
-		project[0], // name
-		project[1], // id
-		project[2], // url
-		project[3], // language
-		project[4], // created_at
-		project[5], // default_branch
+		html.EscapeString(project.Name),
+		html.EscapeString(project.NodeID),
+		html.EscapeString(project.HTMLURL),
+		html.EscapeString(language),
+		project.CreatedAt.Format(time.DateOnly),
+		html.EscapeString(project.DefaultBranch),
 	)
 }
 
@@ -228,11 +83,14 @@ get knowledge stack</code>
 <code><b>Projects:</b>
 get list of complete/under development projects</code>
 
+<code><b>My requests:</b>
+check the state of your job requests (also /status)</code>
+
 Bot will be open source someday (look on my <a href='https://pureheroky.com'>website</a> or in the bot description)
 `
 }
 
-func FormatGitMessagePage(git map[string][]map[string]string, pageIndex int, pageSize int) string {
+func FormatGitMessagePage(git map[string][]github.Commit, pageIndex int, pageSize int) string {
 	message := ""
 	keys := make([]string, 0, len(git))
 
@@ -252,11 +110,11 @@ func FormatGitMessagePage(git map[string][]map[string]string, pageIndex int, pag
 	}
 
 	for _, key := range keys[start:end] {
-		message += fmt.Sprintf("\n\n<b><i>Title: <code>%s</code></i></b>\n", key)
-		for _, value := range git[key] {
-			message += fmt.Sprintf("\nAuthor: <b>%s</b>\n", value["author"])
-			message += fmt.Sprintf("Date: <b>%s</b>\n", value["date"])
-			message += fmt.Sprintf("Message: <b>%s</b>\n", value["message"])
+		message += fmt.Sprintf("\n\n<b><i>Title: <code>%s</code></i></b>\n", html.EscapeString(key))
+		for _, commit := range git[key] {
+			message += fmt.Sprintf("\nAuthor: <b>%s</b>\n", html.EscapeString(commit.Author.Name))
+			message += fmt.Sprintf("Date: <b>%s</b>\n", commit.Committer.Date.Format(ticketTimeLayout))
+			message += fmt.Sprintf("Message: <b>%s</b>\n", html.EscapeString(commit.Message))
 		}
 		message += "\n\n"
 	}
@@ -264,7 +122,7 @@ func FormatGitMessagePage(git map[string][]map[string]string, pageIndex int, pag
 	return message
 }
 
-func SetupLogging() (*log.Logger, *log.Logger) {
+func SetupLogging() (*log.Logger, *log.Logger, *log.Logger) {
 	if _, err := os.Stat("logs"); os.IsNotExist(err) {
 		os.Mkdir("logs", os.ModePerm)
 	}
@@ -283,25 +141,77 @@ func SetupLogging() (*log.Logger, *log.Logger) {
 	}
 	workLogger := log.New(workLogFile, "INFO: ", log.Ldate|log.Ltime)
 
-	return errorLogger, workLogger
-}
+	auditLogFile, err := os.OpenFile("logs/audit.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		errorLogger.Println("Failed to open audit log file:", err)
+		os.Exit(1)
+	}
+	auditLogger := log.New(auditLogFile, "AUDIT: ", log.Ldate|log.Ltime)
 
-func LoadData(dataStore *models.DataStore, apiUrl, username, token string, errorLogger *log.Logger) error {
-	dataStore.Lock()
-	defer dataStore.Unlock()
+	return errorLogger, workLogger, auditLogger
+}
 
-	var err error
-	dataStore.Projects, err = GetProjects(apiUrl, username, token, dataStore)
+// LoadData fetches the projects and their commits from GitHub and swaps them
+// into the data store at once. Nothing is locked while fetching, so the bot keeps
+// serving the previous data, which is also kept unless every fetch succeeded.
+func LoadData(ctx context.Context, dataStore *models.DataStore, client *github.Client, username string, commitLimit int, errorLogger *log.Logger) error {
+	projects, err := client.Repositories(ctx, username)
 	if err != nil {
-		errorLogger.Println("failed to get projects: %w", err)
+		errorLogger.Println("failed to get projects:", err)
 		return fmt.Errorf("failed to get projects: %w", err)
 	}
 
-	dataStore.Git, err = GetGitConcurrently(apiUrl, username, token, dataStore)
+	git, err := client.LatestCommits(ctx, username, projects, commitLimit, errorLogger)
 	if err != nil {
-		errorLogger.Println("failed to get git data: %w", err)
+		errorLogger.Println("failed to get git data:", err)
 		return fmt.Errorf("failed to get git data: %w", err)
 	}
+	// A refresh cut short after the last fetch is still not swapped in.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("refresh cancelled: %w", err)
+	}
+
+	dataStore.Lock()
+	defer dataStore.Unlock()
+
+	dataStore.Projects = projects
+	dataStore.Git = git
 
 	return nil
 }
+
+// FormatTTL renders a duration in words, like "2 minutes" or "1 hour 30 minutes".
+func FormatTTL(d time.Duration) string {
+	units := []struct {
+		size time.Duration
+		name string
+	}{
+		{24 * time.Hour, "day"},
+		{time.Hour, "hour"},
+		{time.Minute, "minute"},
+		{time.Second, "second"},
+	}
+
+	parts := make([]string, 0, 2)
+	for _, unit := range units {
+		count := int(d / unit.size)
+		if count == 0 {
+			continue
+		}
+		d -= time.Duration(count) * unit.size
+
+		part := fmt.Sprintf("%d %s", count, unit.name)
+		if count > 1 {
+			part += "s"
+		}
+		parts = append(parts, part)
+		if len(parts) == 2 {
+			break
+		}
+	}
+
+	if len(parts) == 0 {
+		return "a moment"
+	}
+	return strings.Join(parts, " ")
+}